@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+// DefaultIndex represents default index name of Elasticsearch.
+const DefaultIndex = "logcat"
+
+// BulkWriter writes logcat.Entry as a body of Elasticsearch `_bulk` API.
+// Each entry is written as an action line, followed by a document line.
+type BulkWriter struct {
+	*JSONWriter
+	action []byte
+}
+
+// NewBulkWriter creates new BulkWriter.
+// If `index` is empty, DefaultIndex is used.
+// If `columns` is empty, Columns is used.
+func NewBulkWriter(w io.Writer, index string, columns []string) *BulkWriter {
+	if index == "" {
+		index = DefaultIndex
+	}
+	action := map[string]map[string]string{
+		"index": {"_index": index},
+	}
+	b, _ := json.Marshal(action)
+	return &BulkWriter{
+		JSONWriter: NewJSONWriter(w, columns),
+		action:     append(b, '\n'),
+	}
+}
+
+// Write writes an action line and logcat.Entry as a document.
+func (f *BulkWriter) Write(item logcat.Entry) error {
+	if item == nil {
+		return nil
+	}
+	f.writer.Write(f.action)
+	return f.JSONWriter.Write(item)
+}
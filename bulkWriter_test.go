@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func TestBulkWriter_Write(t *testing.T) {
+
+	entry := logcat.Entry{
+		"time":    "12-28 18:54:07.180",
+		"tag":     "auditd",
+		"message": "test Message",
+	}
+	expected := `{"index":{"_index":"device-logs"}}` + "\n" +
+		`{"time":"12-28 18:54:07.180","tag":"auditd","message":"test Message"}` + "\n"
+
+	writer := new(bytes.Buffer)
+	bulkWriter := NewBulkWriter(writer, "device-logs", nil)
+	bulkWriter.Write(entry)
+	bulkWriter.Write(nil)
+	bulkWriter.Flush()
+
+	if !(writer.String() == expected) {
+		t.Errorf("expected %q to eq %q", writer.String(), expected)
+	}
+}
+
+func TestBulkWriter_Write_Default_Index(t *testing.T) {
+
+	entry := logcat.Entry{
+		"message": "test Message",
+	}
+	expected := `{"index":{"_index":"logcat"}}` + "\n" + `{"message":"test Message"}` + "\n"
+
+	writer := new(bytes.Buffer)
+	bulkWriter := NewBulkWriter(writer, "", nil)
+	bulkWriter.Write(entry)
+	bulkWriter.Flush()
+
+	if !(writer.String() == expected) {
+		t.Errorf("expected %q to eq %q", writer.String(), expected)
+	}
+}
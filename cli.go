@@ -8,6 +8,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // Exit codes are int values that represent an exit code for a particular error.
@@ -29,10 +32,25 @@ type CLI struct {
 }
 
 type cmdParams struct {
-	reader         io.Reader
-	writer, error  io.Writer
-	encode, osName string
-	paths          []string
+	reader          io.Reader
+	writer, error   io.Writer
+	encode, osName  string
+	paths           []string
+	columns         []string
+	format          string
+	delimiter       rune
+	output, source  string
+	index           string
+	filters         filters
+	isoTime         bool
+	year            int
+	modTime         time.Time
+	fromTZ, toTZ    *time.Location
+	epochToTime     bool
+	joinMultiline   bool
+	extractCrashes  bool
+	crashSignatures bool
+	obfuscated      bool
 }
 
 func (cli *CLI) init() {
@@ -45,10 +63,37 @@ func (cli *CLI) init() {
 // Run invokes the CLI with the given arguments.
 func (cli *CLI) Run(args []string, osName string) int {
 	var (
-		encode  string
-		version bool
+		encode     string
+		columns    string
+		format     string
+		delimiter  string
+		output     string
+		index      string
+		filter     string
+		priority   string
+		grep       string
+		grepV      string
+		tagRegexp  string
+		since      string
+		until      string
+		pid        string
+		tid        string
+		process    string
+		isoTime    bool
+		year       int
+		fromTZ     string
+		toTZ       string
+		epoch      bool
+		join       bool
+		crashes    bool
+		signature  bool
+		obfuscated bool
+		version    bool
 	)
 	cli.init()
+	if len(args) > 1 && args[1] == "stats" {
+		return cli.runStats(args)
+	}
 
 	// Define option flag parse
 	flags := flag.NewFlagSet(Name, flag.ContinueOnError)
@@ -56,6 +101,33 @@ func (cli *CLI) Run(args []string, osName string) int {
 	flags.Usage = func() { fmt.Fprintf(cli.outStream, helpText) }
 	flags.StringVar(&encode, "encode", "", "charactor encoding of output file")
 	flags.StringVar(&encode, "e", "", "charactor encoding of output file(Short)")
+	flags.StringVar(&format, "format", FormatCSV, "output format")
+	flags.StringVar(&format, "f", FormatCSV, "output format(Short)")
+	flags.StringVar(&columns, "columns", "", "comma separated list of output columns")
+	flags.StringVar(&delimiter, "delimiter", "", "field delimiter of output file")
+	flags.StringVar(&delimiter, "d", "", "field delimiter of output file(Short)")
+	flags.StringVar(&output, "output", "", "output file which all inputs are written into")
+	flags.StringVar(&output, "o", "", "output file which all inputs are written into(Short)")
+	flags.StringVar(&index, "index", DefaultIndex, "index name of Elasticsearch bulk format")
+	flags.StringVar(&filter, "filter", "", "space separated filterspecs, like adb logcat")
+	flags.StringVar(&priority, "priority", "", "minimum priority of output entries")
+	flags.StringVar(&grep, "grep", "", "regexp which messages of output entries match")
+	flags.StringVar(&grepV, "grep-v", "", "regexp which messages of output entries don't match")
+	flags.StringVar(&tagRegexp, "tag-regex", "", "regexp which tags of output entries match")
+	flags.StringVar(&since, "since", "", "output entries since the time")
+	flags.StringVar(&until, "until", "", "output entries until the time")
+	flags.StringVar(&pid, "pid", "", "comma separated pids of output entries")
+	flags.StringVar(&tid, "tid", "", "comma separated tids of output entries")
+	flags.StringVar(&process, "process", "", "comma separated process names of output entries")
+	flags.BoolVar(&isoTime, "iso-time", false, "output timestamps as ISO 8601, with year")
+	flags.IntVar(&year, "year", 0, "year of the first entry, implies --iso-time")
+	flags.StringVar(&fromTZ, "from-tz", "", "timezone of the log")
+	flags.StringVar(&toTZ, "to-tz", "", "timezone of output timestamps")
+	flags.BoolVar(&epoch, "epoch-to-time", false, "convert epoch time to wall-clock time")
+	flags.BoolVar(&join, "join-multiline", false, "join lines of a stack trace into an entry")
+	flags.BoolVar(&crashes, "extract-crashes", false, "output a summary row per crash or ANR")
+	flags.BoolVar(&signature, "crash-signatures", false, "output a frequency table of crash signatures")
+	flags.BoolVar(&obfuscated, "obfuscated", false, "ignore obfuscated names in crash signatures")
 	flags.BoolVar(&version, "version", false, "Print version information and quit.")
 
 	// Parse commandline flag
@@ -70,16 +142,148 @@ func (cli *CLI) Run(args []string, osName string) int {
 	}
 
 	// Parse arguments
+	outputFormat, err := lookupFormat(format)
+	if err != nil {
+		fmt.Fprintf(cli.errStream, "%s\n", err)
+		return ExitCodeError
+	}
 	params := cmdParams{
-		error:  cli.errStream,
-		encode: encode,
-		osName: osName,
+		error:           cli.errStream,
+		encode:          encode,
+		osName:          osName,
+		format:          format,
+		output:          output,
+		index:           index,
+		isoTime:         isoTime || year != 0,
+		year:            year,
+		epochToTime:     epoch,
+		joinMultiline:   join,
+		extractCrashes:  crashes || signature,
+		crashSignatures: signature,
+		obfuscated:      obfuscated,
+	}
+	if output != "" && outputFormat.newFileWriter == nil {
+		fmt.Fprintf(cli.errStream, "Output file is not supported for %s format\n", format)
+		return ExitCodeError
+	}
+	if columns != "" && format == FormatParquet {
+		fmt.Fprintf(cli.errStream, "Columns option is not supported for %s format\n", format)
+		return ExitCodeError
+	}
+	known := append(append([]string{}, Columns...), DerivedColumns...)
+	if crashes || signature {
+		if format == FormatParquet {
+			fmt.Fprintf(cli.errStream, "Crash extraction is not supported for %s format\n", format)
+			return ExitCodeError
+		}
+		known = CrashColumns
+		params.columns = CrashColumns
+	}
+	if signature {
+		known = SignatureColumns
+		params.columns = SignatureColumns
+	}
+	if columns != "" {
+		cols, err := parseColumns(columns, known)
+		if err != nil {
+			fmt.Fprintf(cli.errStream, "%s\n", err)
+			return ExitCodeError
+		}
+		params.columns = cols
+	}
+	if delimiter != "" {
+		comma, err := parseDelimiter(delimiter)
+		if err != nil {
+			fmt.Fprintf(cli.errStream, "%s\n", err)
+			return ExitCodeError
+		}
+		params.delimiter = comma
+	}
+	targets, specs := splitFilterSpecs(flags.Args())
+	if cli.inStream != nil {
+		specs = flags.Args() // there are no target files.
+	}
+	specs = append(strings.Fields(filter), specs...)
+	if len(specs) > 0 {
+		f, err := newSpecFilter(specs)
+		if err != nil {
+			fmt.Fprintf(cli.errStream, "%s\n", err)
+			return ExitCodeError
+		}
+		params.filters = append(params.filters, f)
+	}
+	if priority != "" {
+		f, err := newPriorityFilter(priority)
+		if err != nil {
+			fmt.Fprintf(cli.errStream, "%s\n", err)
+			return ExitCodeError
+		}
+		params.filters = append(params.filters, f)
+	}
+	for _, r := range []struct {
+		key, expr string
+		invert    bool
+	}{
+		{Message, grep, false},
+		{Message, grepV, true},
+		{"tag", tagRegexp, false},
+	} {
+		if r.expr == "" {
+			continue
+		}
+		f, err := newRegexpFilter(r.key, r.expr, r.invert)
+		if err != nil {
+			fmt.Fprintf(cli.errStream, "%s\n", err)
+			return ExitCodeError
+		}
+		params.filters = append(params.filters, f)
+	}
+	if fromTZ != "" {
+		loc, err := loadLocation(fromTZ)
+		if err != nil {
+			fmt.Fprintf(cli.errStream, "%s\n", err)
+			return ExitCodeError
+		}
+		params.fromTZ = loc
+	}
+	if toTZ != "" {
+		loc, err := loadLocation(toTZ)
+		if err != nil {
+			fmt.Fprintf(cli.errStream, "%s\n", err)
+			return ExitCodeError
+		}
+		params.toTZ = loc
+	}
+	if since != "" || until != "" {
+		f, err := newTimeFilter(since, until)
+		if err != nil {
+			fmt.Fprintf(cli.errStream, "%s\n", err)
+			return ExitCodeError
+		}
+		params.filters = append(params.filters, f)
+	}
+	if pid != "" {
+		params.filters = append(params.filters, newValuesFilter("pid", splitValues(pid)))
+	}
+	if tid != "" {
+		params.filters = append(params.filters, newValuesFilter("tid", splitValues(tid)))
+	}
+	if process != "" {
+		params.filters = append(params.filters, newProcessFilter(splitValues(process)))
 	}
 	if cli.inStream != nil {
 		params.reader = cli.inStream
 		params.writer = cli.outStream
+		params.source = "-"
 	} else {
-		params.paths = cli.expandArgs(flags.Args())
+		ext := outputFormat.ext
+		if signature {
+			params.writer = cli.outStream // the table of all files is written.
+		}
+		if output != "" || signature {
+			ext = "" // don't check existing output files.
+		}
+		params.paths = cli.expandArgs(targets, ext)
 		if len(params.paths) <= 0 {
 			fmt.Fprintf(cli.errStream, "Target not found.\n")
 			return ExitCodeError
@@ -97,7 +301,7 @@ func (cli *CLI) waitForKey() {
 	os.Stdin.Read(buf[:])
 }
 
-func (cli *CLI) listFiles(dirName string) []string {
+func (cli *CLI) listFiles(dirName, ext string) []string {
 	fileInfos, err := ioutil.ReadDir(dirName)
 	if err != nil {
 		return []string{}
@@ -107,7 +311,7 @@ func (cli *CLI) listFiles(dirName string) []string {
 	for _, fileInfo := range fileInfos {
 		// don't list recursively
 		filePath := filepath.Join(dirName, fileInfo.Name())
-		if cli.isValidFile(filePath) {
+		if cli.isValidFile(filePath, ext) {
 			files[i] = filePath
 			i++
 		}
@@ -115,7 +319,7 @@ func (cli *CLI) listFiles(dirName string) []string {
 	return files[:i]
 }
 
-func (cli *CLI) expandArgs(args []string) []string {
+func (cli *CLI) expandArgs(args []string, ext string) []string {
 	if len(args) <= 0 {
 		fmt.Fprintf(cli.errStream, "Please specify a file, or drag & drop to icon.\n")
 		cli.waitForKey()
@@ -131,9 +335,9 @@ func (cli *CLI) expandArgs(args []string) []string {
 	count := 0 // count of files specified directlly.
 	for _, path := range args {
 		if isDir(path) {
-			pathMap[path] = cli.listFiles(path)
+			pathMap[path] = cli.listFiles(path, ext)
 			total = total + len(pathMap[path])
-		} else if cli.isValidFile(path) {
+		} else if cli.isValidFile(path, ext) {
 			files[count] = path
 			total = total + 1
 			count = count + 1
@@ -151,7 +355,7 @@ func (cli *CLI) expandArgs(args []string) []string {
 	return filePaths
 }
 
-func (cli *CLI) isValidFile(file string) bool {
+func (cli *CLI) isValidFile(file, ext string) bool {
 	if s, err := os.Stat(file); err != nil || s.IsDir() {
 		fmt.Fprintf(cli.errStream, "File does not exist: %s\n", file)
 		return false
@@ -160,14 +364,100 @@ func (cli *CLI) isValidFile(file string) bool {
 		fmt.Fprintf(cli.errStream, "Ignore CSV file: %s\n", file)
 		return false
 	}
-	// ignore if csv file is already exists.
-	if _, err := os.Stat(file + ".csv"); err == nil {
-		fmt.Fprintf(cli.errStream, "CSV file already exists: %s\n", file)
+	// ignore if output file is already exists.
+	if _, err := os.Stat(file + ext); ext != "" && err == nil {
+		fmt.Fprintf(cli.errStream, "Output file already exists: %s\n", file)
 		return false
 	}
 	return true
 }
 
+// parseColumns splits comma separated column names, and validates them.
+func parseColumns(str string, known []string) ([]string, error) {
+	cols := strings.Split(str, ",")
+	for i, col := range cols {
+		cols[i] = strings.TrimSpace(col)
+		if !contains(known, cols[i]) {
+			return nil, fmt.Errorf("Unknown column: %s", cols[i])
+		}
+	}
+	return cols, nil
+}
+
+// splitValues splits comma separated values, and trims spaces around them.
+func splitValues(str string) []string {
+	values := strings.Split(str, ",")
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+	return values
+}
+
+// parseDelimiter converts name or character of delimiter to rune.
+func parseDelimiter(str string) (rune, error) {
+	switch str {
+	case "comma":
+		return ',', nil
+	case "tab", "\\t":
+		return '\t', nil
+	case "semicolon":
+		return ';', nil
+	case "pipe":
+		return '|', nil
+	}
+	r := []rune(str)
+	if len(r) != 1 || r[0] == '"' || r[0] == '\r' || r[0] == '\n' || r[0] == utf8.RuneError {
+		return 0, fmt.Errorf("Invalid delimiter: %s", str)
+	}
+	return r[0], nil
+}
+
+// runStats invokes `stats` subcommand, which prints summary statistics of logs.
+func (cli *CLI) runStats(args []string) int {
+	var (
+		asJSON bool
+		top    int
+	)
+	flags := flag.NewFlagSet(Name+" stats", flag.ContinueOnError)
+	flags.SetOutput(cli.errStream)
+	flags.Usage = func() { fmt.Fprintf(cli.outStream, helpText) }
+	flags.BoolVar(&asJSON, "json", false, "print statistics as JSON")
+	flags.IntVar(&top, "top", DefaultTop, "count of tags, pids and seconds to print")
+	if err := flags.Parse(args[2:]); err != nil {
+		return ExitCodeError
+	}
+
+	s := newStats()
+	if cli.inStream != nil {
+		s.Read(cli.inStream)
+	} else {
+		paths := cli.expandArgs(flags.Args(), "")
+		if len(paths) <= 0 {
+			fmt.Fprintf(cli.errStream, "Target not found.\n")
+			return ExitCodeError
+		}
+		for _, path := range paths {
+			r, err := os.Open(path)
+			if err != nil {
+				fmt.Fprintf(cli.errStream, "File open error: %s\n", path)
+				continue
+			}
+			s.Read(r)
+			r.Close()
+		}
+	}
+
+	if asJSON {
+		if err := s.WriteJSON(cli.outStream, top); err != nil {
+			fmt.Fprintf(cli.errStream, "%s\n", err)
+			return ExitCodeError
+		}
+	} else {
+		s.WriteText(cli.outStream, top)
+	}
+	return ExitCodeOK
+}
+
 func isDir(file string) bool {
 	if s, err := os.Stat(file); err == nil && s.IsDir() {
 		return true
@@ -180,10 +470,69 @@ var helpText = `logcat2csv is tool for convert logcat to csv.
 https://github.com/ujiro99/logcat2csv
 
 Usage:
-  logcat2csv [options] PATH ...
+  logcat2csv [options] PATH ... [FILTERSPEC ...]
+  logcat2csv stats [--json] [--top N] PATH ...
+
+FilterSpec:
+  TAG:PRIORITY, same as adb logcat. e.g. "ActivityManager:I MyApp:V *:S"
+  PRIORITY is one of V, D, I, W, E, F, S.
 
 Options:
+  --format, -f   Output format. (csv, jsonl, xlsx, sqlite, html, markdown,
+                 parquet, bulk)
+  --output, -o   Output file which all inputs are written into. (sqlite only)
+  --index        Index name of Elasticsearch bulk format. (default: logcat)
   --encode, -e   Charactor encoding of output file.
+  --delimiter, -d
+                 Field delimiter of output file. (comma, tab, semicolon, pipe)
+  --filter       Space separated filterspecs.
+  --priority     Minimum priority of output entries. (V, D, I, W, E, F)
+  --grep         Regexp which messages of output entries match.
+  --grep-v       Regexp which messages of output entries don't match.
+  --tag-regex    Regexp which tags of output entries match.
+  --since, --until
+                 Time range of output entries, "MM-DD HH:MM:SS[.mmm]"
+                 or offset from the first entry, like "+5m".
+  --pid, --tid   Comma separated pids / tids of output entries.
+  --process      Comma separated process names of output entries.
+                 Pids are resolved from "Start proc" lines of ActivityManager.
+  --iso-time     Output timestamps as ISO 8601, like "2017-12-28T18:54:07.180".
+                 The year is inferred from modification time of the file.
+                 The offset is appended, if the log has it, like "-v zone".
+  --year         Year of the first entry. (implies --iso-time)
+  --from-tz      Timezone of the log, used with --to-tz.
+                 (default: local timezone, or timezone printed by "-v zone")
+  --to-tz        Timezone of output timestamps, like "UTC".
+  --epoch-to-time
+                 Convert epoch time of "-v epoch" to wall-clock time, as ISO 8601
+                 with year and timezone. (in --from-tz, or local timezone)
+  --join-multiline
+                 Join consecutive lines of a stack trace into an entry,
+                 which have the same pid, tid, tag and timestamp,
+                 or look like "at ..." or "Caused by: ...".
+  --extract-crashes
+                 Output a summary row per incident, instead of entries.
+                 (FATAL EXCEPTION, native tombstone, ANR and am_crash)
+                 Columns are time,process,pid,exception,frame,trace.
+  --crash-signatures
+                 Output a frequency table of crashes in all inputs, instead
+                 of converted files. Java stack traces are normalized by
+                 stripping line numbers, lambdas and messages.
+                 Columns are signature,count,files,exception,frame,trace.
+  --obfuscated   Ignore obfuscated names in crash signatures, like "a.b.c".
+  --columns      Comma separated list of output columns.
+                 (time,pid,tid,priority,tag,message)
+                 Derived columns are also available:
+                   process    Process name, resolved from ActivityManager events.
+                   elapsed    Seconds since the first entry.
+                   delta      Seconds since the previous entry.
+                   pid_delta  Seconds since the previous entry of the same pid.
   --version      Show version.
   --help         Show this help.
+
+Stats:
+  Print counts per priority, tag and pid, lines-per-second histogram,
+  and count of lines which can't be parsed.
+  --json         Print statistics as JSON.
+  --top          Count of tags, pids and peak seconds to print. (default: 10)
 `
@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/text/encoding/japanese"
 	"golang.org/x/text/transform"
@@ -82,7 +86,7 @@ func TestRun_Not_File(t *testing.T) {
 }
 
 func TestRun_Exec_Stdio(t *testing.T) {
-	expect := "01-01 00:00:00.000,930,931,I,tag_value,message_value\n"
+	expect := header + "\n" + "01-01 00:00:00.000,930,931,I,tag_value,message_value\n"
 
 	inStream := strings.NewReader("01-01 00:00:00.000   930   931 I tag_value  : message_value")
 	outStream := new(bytes.Buffer)
@@ -100,6 +104,7 @@ func TestRun_Exec_Stdio(t *testing.T) {
 
 func TestRun_encodeFlag(t *testing.T) {
 	expect := []string{
+		header,
 		convertTo("01-01 00:00:00.000,930,931,I,tag_value,message_value_1", ShiftJIS),
 		convertTo("01-01 00:00:01.000,930,931,I,tag_value,message_value_あ亜Ａア￥凜熙♪堯", ShiftJIS),
 	}
@@ -118,6 +123,7 @@ func TestRun_encodeFlag(t *testing.T) {
 
 func TestRun_encodeFlag_output_with_utf8_if_encoding_failed(t *testing.T) {
 	expect := []string{
+		header,
 		convertTo("01-01 00:00:01.000,930,931,I,tag_value,message_value_あ亜Ａア￥凜熙♪堯", ShiftJIS),
 		convertTo("01-01 00:00:01.000,930,931,I,tag_value,\"AddressBook Labels [en-US]: [, A, B, C, D, E, F, G, H, I, J, K, L, M, N, O, P, Q, R, S, T, U, V, W, X, Y, Z, Α, Β, Γ, Δ, Ε, Ζ, Η, Θ, Ι, Κ, Λ, Μ, Ν, Ξ, Ο, Π, Ρ, Σ, Τ, Υ, Φ, Χ, Ψ, Ω, , А, Б, В, Г, Д, Ђ, Е, Є, Ж, З, И, І, Й, Ј, К, Л, Љ, М, Н, Њ, О, П, Р, С, Т, Ћ, У, Ф, Х, Ц, Ч, Џ, Ш, Щ, Ю, Я, , א, ב, ג, ד, ה, ו, ז, ח, ט, י, כ, ל, מ, נ, ס, ע, פ, צ, ק, ר, ש, ת, , ا, ب, ت, ث, ج, ح, خ, د, ذ, ر, ز, س, ش, ص, ض, ط, ظ, ع, غ, ف, ق, ك, ل, م, ن, ه, و, ي, , ก, ข, ฃ, ค, ฅ, ฆ, ง, จ, ฉ, ช, ซ, ฌ, ญ, ฎ, ฏ, ฐ, ฑ, ฒ, ณ, ด, ต, ถ, ท, ธ, น, บ, ป, ผ, ฝ, พ, ฟ, ภ, ม, ย, ร, ฤ, ล, ฦ, ว, ศ, ษ, ส, ห, ฬ, อ, ฮ, , ㄱ, ㄴ, ㄷ, ㄹ, ㅁ, ㅂ, ㅅ, ㅇ, ㅈ, ㅊ, ㅋ, ㅌ, ㅍ, ㅎ, , あ, か, さ, た, な, は, ま, や, ら, わ, #, ]\"", UTF8),
 		convertTo("01-01 00:00:01.000,930,931,I,tag_value,\"AddressBook Labels [en-US]: [, A, B, C, D, E, F, G, H, I, J, K, L, M, N, O, P, Q, R, S, T, U, V, W, X, Y, Z]\"", ShiftJIS),
@@ -135,12 +141,48 @@ func TestRun_encodeFlag_output_with_utf8_if_encoding_failed(t *testing.T) {
 	}
 }
 
+func TestRun_columnsFlag(t *testing.T) {
+	expect := "time,priority,tag,message\n" + "01-01 00:00:00.000,I,tag_value,message_value\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   930   931 I tag_value  : message_value")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv --columns time,priority,tag,message", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_columnsFlag_Unknown(t *testing.T) {
+	expect := "Unknown column: level\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   930   931 I tag_value  : message_value")
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream, errStream: errStream}
+	args := strings.Split("./logcat2csv --columns time,level", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeError {
+		t.Errorf("expected %d to eq %d", status, ExitCodeError)
+	}
+	if errStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", errStream.String(), expect)
+	}
+}
+
 func TestRun_Exec_Multiple_File(t *testing.T) {
 	expect0 := []string{
+		header,
 		"01-01 00:00:00.000,930,931,I,tag_value,message_value_1",
 		"01-01 00:00:01.000,930,931,I,tag_value,message_value_2",
 	}
 	expect1 := []string{
+		header,
 		"01-01 00:00:00.000,930,931,I,tag_value,message_value_3",
 		"01-01 00:00:01.000,930,931,I,tag_value,message_value_4",
 	}
@@ -162,6 +204,7 @@ func TestRun_Exec_Multiple_File(t *testing.T) {
 
 func TestRun_Exec_File_Not_File(t *testing.T) {
 	expect0 := []string{
+		header,
 		"01-01 00:00:00.000,930,931,I,tag_value,message_value_1",
 		"01-01 00:00:01.000,930,931,I,tag_value,message_value_2",
 	}
@@ -251,3 +294,693 @@ func TestRun_Exec_Directory_ignore_if_csv_already_exists(t *testing.T) {
 		checkFile(path, nil)
 	}
 }
+
+func TestRun_formatFlag_jsonl(t *testing.T) {
+	expect := []string{
+		`{"time":"01-01 00:00:00.000","pid":"930","tid":"931","priority":"I","tag":"tag_value","message":"message_value_1"}`,
+		`{"time":"01-01 00:00:01.000","pid":"930","tid":"931","priority":"I","tag":"tag_value","message":"message_value_2"}`,
+	}
+	cli := &CLI{inStream: nil}
+	args := strings.Split("./logcat2csv --format jsonl test/logcat.txt", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if err := checkOutput(args[3], ".jsonl", expect); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRun_formatFlag_Unknown(t *testing.T) {
+	expect := "Unknown format: xml\n"
+	errStream := new(bytes.Buffer)
+	cli := &CLI{inStream: nil, errStream: errStream}
+	args := strings.Split("./logcat2csv --format xml test/logcat.txt", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeError {
+		t.Errorf("expected %d to eq %d", status, ExitCodeError)
+	}
+	if errStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", errStream.String(), expect)
+	}
+}
+
+func TestRun_delimiterFlag(t *testing.T) {
+	expect := "time\tpid\ttid\tpriority\ttag\tmessage\n" + "01-01 00:00:00.000\t930\t931\tI\ttag_value\tmessage_value, 1\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   930   931 I tag_value  : message_value, 1")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv --delimiter tab", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_delimiterFlag_Invalid(t *testing.T) {
+	expect := "Invalid delimiter: ::\n"
+	errStream := new(bytes.Buffer)
+	cli := &CLI{inStream: strings.NewReader(""), errStream: errStream}
+	args := strings.Split("./logcat2csv -d ::", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeError {
+		t.Errorf("expected %d to eq %d", status, ExitCodeError)
+	}
+	if errStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", errStream.String(), expect)
+	}
+}
+
+func TestRun_outputFlag_sqlite(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "logcat2csv")
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "logcat.db")
+
+	cli := &CLI{inStream: nil}
+	args := []string{"logcat2csv", "-f", "sqlite", "-o", output, "test/logcat.txt", "test/logcat2.txt"}
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+
+	expected := [][]interface{}{
+		{"test/logcat.txt", "message_value_1"},
+		{"test/logcat.txt", "message_value_2"},
+		{"test/logcat2.txt", "message_value_3"},
+		{"test/logcat2.txt", "message_value_4"},
+	}
+	rows := querySqlite(t, output, "SELECT source, message FROM logcat ORDER BY source, id")
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %v to eq %v", rows, expected)
+	}
+}
+
+func TestRun_outputFlag_Not_Supported(t *testing.T) {
+	expect := "Output file is not supported for csv format\n"
+	errStream := new(bytes.Buffer)
+	cli := &CLI{inStream: nil, errStream: errStream}
+	args := strings.Split("./logcat2csv -o out.csv test/logcat.txt", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeError {
+		t.Errorf("expected %d to eq %d", status, ExitCodeError)
+	}
+	if errStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", errStream.String(), expect)
+	}
+}
+
+func TestRun_filterSpecs(t *testing.T) {
+	expect := header + "\n" + "01-01 00:00:01.000,930,931,W,MyApp,message_value_2\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   930   931 D MyApp  : message_value_1\n" +
+		"01-01 00:00:01.000   930   931 W MyApp  : message_value_2\n" +
+		"01-01 00:00:02.000   930   931 E Other  : message_value_3\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv MyApp:I *:S", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_filterFlag(t *testing.T) {
+	expect := header + "\n" + "01-01 00:00:02.000,930,931,E,Other,message_value_3\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   930   931 D MyApp  : message_value_1\n" +
+		"01-01 00:00:01.000   930   931 W MyApp  : message_value_2\n" +
+		"01-01 00:00:02.000   930   931 E Other  : message_value_3\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := []string{"./logcat2csv", "--filter", "MyApp:S *:E"}
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_priorityFlag(t *testing.T) {
+	expect := header + "\n" + "01-01 00:00:01.000,930,931,W,MyApp,message_value_2\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   930   931 D MyApp  : message_value_1\n" +
+		"01-01 00:00:01.000   930   931 W MyApp  : message_value_2\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv --priority W", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_priorityFlag_Count_Filtered_Entries(t *testing.T) {
+	expect := header + "\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   930   931 D MyApp  : message_value_1\n" +
+		"01-01 00:00:01.000   930   931 I MyApp  : message_value_2\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv --priority E", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_grepFlag(t *testing.T) {
+	expect := header + "\n" + "01-01 00:00:01.000,930,931,E,AndroidRuntime,FATAL EXCEPTION: main\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   930   931 I ActivityManager: Start proc 930:com.foo/u0a1\n" +
+		"01-01 00:00:01.000   930   931 E AndroidRuntime: FATAL EXCEPTION: main\n" +
+		"01-01 00:00:01.000   930   931 E AndroidRuntime: Process: com.foo, PID: 930\n" +
+		"01-01 00:00:02.000   930   931 E MyApp  : ANR ignored\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := []string{"./logcat2csv", "--grep", "FATAL|ANR|PID", "--grep-v", "Process:", "--tag-regex", "^Android"}
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_sinceUntilFlag(t *testing.T) {
+	expect := header + "\n" +
+		"01-01 00:01:00.000,930,931,I,MyApp,message_value_2\n" +
+		"01-01 00:02:00.000,930,931,I,MyApp,message_value_3\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   930   931 D Other  : message_value_1\n" +
+		"01-01 00:01:00.000   930   931 I MyApp  : message_value_2\n" +
+		"01-01 00:02:00.000   930   931 I MyApp  : message_value_3\n" +
+		"01-01 00:03:00.000   930   931 I MyApp  : message_value_4\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := []string{"./logcat2csv", "--since", "+1m", "--until", "01-01 00:02:00", "MyApp:V", "*:S"}
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_processFlag(t *testing.T) {
+	expect := header + "\n" +
+		"01-01 00:00:01.000,2000,2001,I,MyApp,message_value_2\n" +
+		"01-01 00:00:03.000,3000,3000,I,MyApp,message_value_4\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   500   510 I ActivityManager: Start proc 2000:com.example.app/u0a123 for activity\n" +
+		"01-01 00:00:01.000  2000  2001 I MyApp  : message_value_2\n" +
+		"01-01 00:00:02.000   500   510 I ActivityManager: Start proc 3000:com.example.app/u0a123 for service\n" +
+		"01-01 00:00:03.000  3000  3000 I MyApp  : message_value_4\n" +
+		"01-01 00:00:03.000  2000  2002 I MyApp  : message_value_5\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := []string{"./logcat2csv", "--process", "com.example.app", "--tid", "2001,3000"}
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_processFlag_Spaces(t *testing.T) {
+	expect := header + "\n" +
+		"01-01 00:00:01.000,2000,2001,I,MyApp,message_value_2\n" +
+		"01-01 00:00:03.000,3000,3000,I,MyApp,message_value_4\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   500   510 I ActivityManager: Start proc 2000:com.example.app/u0a123 for activity\n" +
+		"01-01 00:00:01.000  2000  2001 I MyApp  : message_value_2\n" +
+		"01-01 00:00:02.000   500   510 I ActivityManager: Start proc 3000:com.example.app/u0a123 for service\n" +
+		"01-01 00:00:03.000  3000  3000 I MyApp  : message_value_4\n" +
+		"01-01 00:00:03.000  2000  2002 I MyApp  : message_value_5\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := []string{"./logcat2csv", "--process", "other, com.example.app", "--pid", "2000, 3000", "--tid", "2001, 3000"}
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_columnsFlag_Process(t *testing.T) {
+	expect := "pid,process,message\n" +
+		"500,,Start proc 2000:com.example.app/u0a123 for activity\n" +
+		"2000,com.example.app,message_value_2\n" +
+		"500,,Process com.example.app (pid 2000) has died\n" +
+		"2000,,message_value_4\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   500   510 I ActivityManager: Start proc 2000:com.example.app/u0a123 for activity\n" +
+		"01-01 00:00:01.000  2000  2001 I MyApp  : message_value_2\n" +
+		"01-01 00:00:02.000   500   510 I ActivityManager: Process com.example.app (pid 2000) has died\n" +
+		"01-01 00:00:03.000  2000  2001 I MyApp  : message_value_4\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := []string{"./logcat2csv", "--columns", "pid,process,message"}
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_yearFlag(t *testing.T) {
+	expect := header + "\n" +
+		"2017-12-31T23:59:59.000,930,931,I,tag_value,message_value_1\n" +
+		"2018-01-01T00:00:00.000,930,931,I,tag_value,message_value_2\n"
+
+	inStream := strings.NewReader("12-31 23:59:59.000   930   931 I tag_value  : message_value_1\n" +
+		"01-01 00:00:00.000   930   931 I tag_value  : message_value_2\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv --year 2017", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_isoTimeFlag(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "logcat2csv")
+	defer os.RemoveAll(dir)
+	input := filepath.Join(dir, "logcat.txt")
+	ioutil.WriteFile(input, []byte("01-01 00:00:00.000   930   931 I tag_value  : message_value_1\n"+
+		"01-01 00:00:01.000   930   931 I tag_value  : message_value_2\n"), 0644)
+	mtime := time.Date(2018, 1, 1, 0, 0, 5, 0, time.Local)
+	os.Chtimes(input, mtime, mtime)
+	expect := []string{
+		header,
+		"2018-01-01T00:00:00.000,930,931,I,tag_value,message_value_1",
+		"2018-01-01T00:00:01.000,930,931,I,tag_value,message_value_2",
+	}
+	cli := &CLI{inStream: nil}
+	args := []string{"./logcat2csv", "--iso-time", input}
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if err := checkFile(args[2], expect); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRun_timezoneFlag(t *testing.T) {
+	expect := header + "\n" + "2017-12-28T09:54:07.180Z,930,931,I,tag_value,message_value\n"
+
+	inStream := strings.NewReader("12-28 18:54:07.180   930   931 I tag_value  : message_value")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv --year 2017 --from-tz Asia/Tokyo --to-tz UTC", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_columnsFlag_Elapsed(t *testing.T) {
+	expect := "time,elapsed,delta,pid_delta\n" +
+		"01-01 00:00:01.000,1.000,0.500,1.000\n"
+
+	inStream := strings.NewReader("01-01 00:00:00.000   930   931 I MyApp  : message_value_1\n" +
+		"01-01 00:00:00.500   100   100 I Other  : message_value_2\n" +
+		"01-01 00:00:01.000   930   931 I MyApp  : message_value_3\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := []string{"./logcat2csv", "--columns", "time,elapsed,delta,pid_delta", "--since", "01-01 00:00:01"}
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_Exec_Epoch(t *testing.T) {
+	expect := header + "\n" +
+		"1514454847.180,930,931,I,tag_value,message_value_1\n" +
+		"1514454848.180,930,931,I,tag_value,message_value_2\n"
+
+	inStream := strings.NewReader("1514454847.180   930   931 I tag_value  : message_value_1\n" +
+		"1514454848.180   930   931 I tag_value  : message_value_2\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_epochToTimeFlag(t *testing.T) {
+	expect := header + "\n" + "2017-12-28T18:54:07.180+09:00,930,931,I,tag_value,message_value\n"
+
+	inStream := strings.NewReader("1514454847.180   930   931 I tag_value  : message_value")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv --epoch-to-time --from-tz Asia/Tokyo --to-tz Asia/Tokyo --year 2017", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_Exec_Long(t *testing.T) {
+	expect := header + "\n" +
+		"12-28 18:54:07.180,930,931,I,tag_value,message_value_1\n" +
+		"12-28 18:54:08.180,930,931,W,tag_value,\"message_value_2\nmessage_value_3\"\n"
+
+	inStream := strings.NewReader("[ 12-28 18:54:07.180   930:  931 I/tag_value ]\n" +
+		"message_value_1\n" +
+		"\n" +
+		"[ 12-28 18:54:08.180   930:  931 W/tag_value ]\n" +
+		"message_value_2\n" +
+		"message_value_3\n" +
+		"\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_joinMultilineFlag(t *testing.T) {
+	expect := header + "\n" +
+		"12-28 18:54:07.180,930,931,E,AndroidRuntime,\"FATAL EXCEPTION: main\n\tat com.example.Main.run(Main.java:10)\"\n" +
+		"12-28 18:54:08.180,930,931,I,tag_value,message_value\n"
+
+	inStream := strings.NewReader("12-28 18:54:07.180   930   931 E AndroidRuntime: FATAL EXCEPTION: main\n" +
+		"12-28 18:54:07.181   930   931 E AndroidRuntime: \tat com.example.Main.run(Main.java:10)\n" +
+		"12-28 18:54:08.180   930   931 I tag_value: message_value\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv --join-multiline", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_extractCrashesFlag(t *testing.T) {
+	expect := "time,process,pid,exception,frame,trace\n" +
+		"12-28 18:54:07.180,com.example.app,1234,java.lang.IllegalStateException,com.example.app.Main.run(Main.java:10)," +
+		"\"FATAL EXCEPTION: main\nProcess: com.example.app, PID: 1234\njava.lang.IllegalStateException\n\tat com.example.app.Main.run(Main.java:10)\"\n"
+
+	inStream := strings.NewReader("12-28 18:54:07.000  1234  1234 I tag_value: message_value\n" +
+		"12-28 18:54:07.180  1234  1234 E AndroidRuntime: FATAL EXCEPTION: main\n" +
+		"12-28 18:54:07.180  1234  1234 E AndroidRuntime: Process: com.example.app, PID: 1234\n" +
+		"12-28 18:54:07.180  1234  1234 E AndroidRuntime: java.lang.IllegalStateException\n" +
+		"12-28 18:54:07.180  1234  1234 E AndroidRuntime: \tat com.example.app.Main.run(Main.java:10)\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv --extract-crashes", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_extractCrashesFlag_encodeFlag(t *testing.T) {
+	expect := convertTo("time,process,pid,exception,frame,trace\n", ShiftJIS) +
+		convertTo("12-28 18:54:07.180,com.example.app,1234,java.lang.IllegalStateException,,"+
+			"\"FATAL EXCEPTION: main\nProcess: com.example.app, PID: 1234\njava.lang.IllegalStateException: ☃\"\n", UTF8) +
+		convertTo("12-28 18:54:09.180,com.example.app,1235,java.lang.IllegalStateException,,"+
+			"\"FATAL EXCEPTION: main\nProcess: com.example.app, PID: 1235\njava.lang.IllegalStateException: エラー\"\n", ShiftJIS)
+
+	inStream := strings.NewReader("12-28 18:54:07.180  1234  1234 E AndroidRuntime: FATAL EXCEPTION: main\n" +
+		"12-28 18:54:07.180  1234  1234 E AndroidRuntime: Process: com.example.app, PID: 1234\n" +
+		"12-28 18:54:07.180  1234  1234 E AndroidRuntime: java.lang.IllegalStateException: ☃\n" +
+		"12-28 18:54:09.180  1235  1235 E AndroidRuntime: FATAL EXCEPTION: main\n" +
+		"12-28 18:54:09.180  1235  1235 E AndroidRuntime: Process: com.example.app, PID: 1235\n" +
+		"12-28 18:54:09.180  1235  1235 E AndroidRuntime: java.lang.IllegalStateException: エラー\n")
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream, errStream: errStream}
+	args := strings.Split("./logcat2csv --extract-crashes --encode shift-jis", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
+
+func TestRun_extractCrashesFlag_Columns(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &CLI{inStream: strings.NewReader(""), outStream: outStream, errStream: errStream}
+	args := strings.Split("./logcat2csv --extract-crashes --columns time,tag", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeError {
+		t.Errorf("expected %d to eq %d", status, ExitCodeError)
+	}
+	expect := "Unknown column: tag\n"
+	if errStream.String() != expect {
+		t.Errorf("expected %q to eq %q", errStream.String(), expect)
+	}
+}
+
+func TestRun_crashSignaturesFlag(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "logcat2csv")
+	defer os.RemoveAll(dir)
+	for i, line := range []string{"10", "12"} {
+		log := "12-28 18:54:07.180  1234  1234 E AndroidRuntime: FATAL EXCEPTION: main\n" +
+			"12-28 18:54:07.180  1234  1234 E AndroidRuntime: java.lang.IllegalStateException\n" +
+			"12-28 18:54:07.180  1234  1234 E AndroidRuntime: \tat com.example.app.Main.run(Main.java:" + line + ")\n"
+		ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("logcat%d.txt", i)), []byte(log), 0644)
+	}
+
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: nil, outStream: outStream}
+	args := []string{"logcat2csv", "--crash-signatures", "--columns", "count,files,exception,trace", dir}
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	expect := "count,files,exception,trace\n" +
+		"2,2,java.lang.IllegalStateException,\"java.lang.IllegalStateException\nat com.example.app.Main.run(Main.java)\"\n"
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.csv")); len(files) != 0 {
+		t.Errorf("expected %v to be empty", files)
+	}
+}
+
+func TestRun_stats_JSON(t *testing.T) {
+	expect := `{
+  "entries": 2,
+  "failures": 1,
+  "priorities": {
+    "I": 2
+  },
+  "tags": [
+    {
+      "value": "tag_value",
+      "count": 2
+    }
+  ],
+  "pids": [
+    {
+      "value": "930",
+      "count": 2
+    }
+  ],
+  "lines_per_second": [
+    {
+      "range": "1-9",
+      "seconds": 1
+    },
+    {
+      "range": "10-99",
+      "seconds": 0
+    },
+    {
+      "range": "100-999",
+      "seconds": 0
+    },
+    {
+      "range": "1000-9999",
+      "seconds": 0
+    },
+    {
+      "range": "10000-",
+      "seconds": 0
+    }
+  ],
+  "peak_seconds": [
+    {
+      "value": "12-28 18:54:07",
+      "count": 2
+    }
+  ]
+}
+`
+
+	inStream := strings.NewReader("12-28 18:54:07.180   930   931 I tag_value  : message_value_1\n" +
+		"12-28 18:54:07.280   930   931 I tag_value  : message_value_2\n" +
+		"not a logcat line\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := strings.Split("./logcat2csv stats --json --top 1", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %s\n  expect: %s", outStream.String(), expect)
+	}
+}
+
+func TestRun_stats_Files(t *testing.T) {
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: nil, outStream: outStream}
+	args := strings.Split("./logcat2csv stats test/logcat.txt test/logcat2.txt", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if !strings.HasPrefix(outStream.String(), "Entries: 4\n") {
+		t.Errorf("expected %q to start with %q", outStream.String(), "Entries: 4\n")
+	}
+}
+
+func TestRun_epochToTimeFlag_Year(t *testing.T) {
+	tests := []struct {
+		args, expect string
+	}{
+		{"--epoch-to-time --from-tz Asia/Tokyo", "2017-12-28T18:54:07.180+09:00"},
+		{"--epoch-to-time --from-tz Asia/Tokyo --iso-time", "2017-12-28T18:54:07.180+09:00"},
+		{"--epoch-to-time --from-tz Asia/Tokyo --to-tz UTC --iso-time", "2017-12-28T09:54:07.180Z"},
+	}
+
+	for _, test := range tests {
+		inStream := strings.NewReader("1514454847.180   930   931 I tag_value  : message_value")
+		outStream := new(bytes.Buffer)
+		cli := &CLI{inStream: inStream, outStream: outStream}
+		args := append([]string{"./logcat2csv"}, strings.Split(test.args, " ")...)
+
+		status := cli.Run(args, "")
+		if status != ExitCodeOK {
+			t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+		}
+		expect := header + "\n" + test.expect + ",930,931,I,tag_value,message_value\n"
+		if outStream.String() != expect {
+			t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+		}
+	}
+}
+
+func TestRun_columnsFlag_Parquet(t *testing.T) {
+	expect := "Columns option is not supported for parquet format\n"
+	errStream := new(bytes.Buffer)
+	cli := &CLI{inStream: nil, errStream: errStream}
+	args := strings.Split("./logcat2csv -f parquet --columns time,elapsed test/logcat.txt", " ")
+
+	status := cli.Run(args, "")
+	if status != ExitCodeError {
+		t.Errorf("expected %d to eq %d", status, ExitCodeError)
+	}
+	if errStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", errStream.String(), expect)
+	}
+}
+
+func TestRun_sinceUntilFlag_Rollover(t *testing.T) {
+	expect := header + "\n" +
+		"12-31 23:30:00.000,930,931,I,tag_value,message_value_2\n" +
+		"01-01 00:30:00.000,930,931,I,tag_value,message_value_3\n"
+
+	inStream := strings.NewReader("12-31 22:00:00.000   930   931 I tag_value  : message_value_1\n" +
+		"12-31 23:30:00.000   930   931 I tag_value  : message_value_2\n" +
+		"01-01 00:30:00.000   930   931 I tag_value  : message_value_3\n" +
+		"01-01 02:00:00.000   930   931 I tag_value  : message_value_4\n")
+	outStream := new(bytes.Buffer)
+	cli := &CLI{inStream: inStream, outStream: outStream}
+	args := []string{"./logcat2csv", "--since", "12-31 23:00:00", "--until", "01-01 01:00:00"}
+
+	status := cli.Run(args, "")
+	if status != ExitCodeOK {
+		t.Errorf("expected %d to eq %d", status, ExitCodeOK)
+	}
+	if outStream.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", outStream.String(), expect)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+// Columns of crash reports.
+const (
+	ExceptionColumn = "exception"
+	FrameColumn     = "frame"
+	TraceColumn     = "trace"
+)
+
+// CrashColumns represents columns of crash reports.
+var CrashColumns = []string{"time", ProcessColumn, "pid", ExceptionColumn, FrameColumn, TraceColumn}
+
+// crashBurst represents max interval between lines of an incident.
+const crashBurst = time.Second
+
+// kinds of incidents.
+const (
+	crashJava   = "java"
+	crashNative = "native"
+	crashANR    = "anr"
+	crashEvent  = "am_crash"
+)
+
+var (
+	// e.g. `FATAL EXCEPTION: main`
+	fatalRegexp = regexp.MustCompile(`^FATAL EXCEPTION: `)
+	// e.g. `Process: com.example.app, PID: 1234`
+	crashProcessRegexp = regexp.MustCompile(`^Process: ([^\s,]+), PID: (\d+)`)
+	// e.g. `java.lang.NullPointerException: Attempt to invoke virtual method ...`
+	exceptionRegexp = regexp.MustCompile(`^([\w$]+(?:\.[\w$]+)+)(?::|$)`)
+	// e.g. `at com.example.app.Main.run(Main.java:10)`
+	frameRegexp = regexp.MustCompile(`^at (.+)$`)
+	// e.g. `*** *** *** *** *** *** *** *** *** *** *** *** *** *** *** ***`
+	tombstoneRegexp = regexp.MustCompile(`^\*\*\* \*\*\* \*\*\*`)
+	// e.g. `pid: 1234, tid: 1240, name: RenderThread  >>> com.example.app <<<`
+	tombstoneProcessRegexp = regexp.MustCompile(`^pid: (\d+), tid: \d+, name: .*>>> (.+) <<<`)
+	// e.g. `signal 11 (SIGSEGV), code 1 (SEGV_MAPERR), fault addr 0x0`
+	signalRegexp = regexp.MustCompile(`^signal \d+ \((\w+)\)`)
+	// e.g. `#00 pc 000000000001d3f0  /system/lib64/libc.so (strlen+16)`
+	nativeFrameRegexp = regexp.MustCompile(`^#00 pc \S+\s+(.+)$`)
+	// e.g. `ANR in com.example.app (com.example.app/.MainActivity)`
+	anrRegexp = regexp.MustCompile(`^ANR in (\S+)`)
+	// e.g. `PID: 1234`
+	anrPidRegexp = regexp.MustCompile(`^PID: (\d+)`)
+	// e.g. `Reason: Input dispatching timed out`
+	anrReasonRegexp = regexp.MustCompile(`^Reason: (.+)$`)
+)
+
+// crash represents an incident, which is being read.
+type crash struct {
+	kind  string
+	key   string
+	entry logcat.Entry // the first entry of the incident.
+	lines []string
+	last  time.Time
+}
+
+// crashExtractor extracts crashes and ANRs from entries, and summarizes each incident to a row.
+// Entries must be given in order of the log.
+type crashExtractor struct {
+	pending []*crash
+	crashed map[string]bool // pids of java crashes, to skip duplicated am_crash events.
+	years   *yearResolver
+}
+
+func newCrashExtractor() *crashExtractor {
+	return &crashExtractor{crashed: map[string]bool{}, years: newRolloverResolver()}
+}
+
+// Extract reads `entry`, and returns rows of incidents which are completed.
+func (c *crashExtractor) Extract(entry logcat.Entry) []logcat.Entry {
+	var rows []logcat.Entry
+	now, err := resolveTime(c.years, entry["time"])
+	if err == nil {
+		rows = c.expire(now)
+	}
+
+	key := entry["pid"] + "/" + entry["tid"] + "/" + entry["tag"]
+	lines := strings.Split(entry[Message], "\n")
+	if kind := crashKind(entry, lines[0]); kind == crashEvent {
+		if row := c.event(entry); row != nil {
+			rows = append(rows, row)
+		}
+		return rows
+	} else if kind != "" {
+		if i := c.find(key); i >= 0 {
+			rows = append(rows, c.close(i))
+		}
+		if kind == crashJava {
+			c.crashed[entry["pid"]] = true
+		}
+		c.pending = append(c.pending, &crash{kind: kind, key: key, entry: entry})
+	}
+	if i := c.find(key); i >= 0 {
+		p := c.pending[i]
+		p.lines = append(p.lines, lines...)
+		if err == nil {
+			p.last = now
+		}
+	}
+	return rows
+}
+
+// Flush returns rows of all incidents which are being read.
+func (c *crashExtractor) Flush() []logcat.Entry {
+	var rows []logcat.Entry
+	for len(c.pending) > 0 {
+		rows = append(rows, c.close(0))
+	}
+	return rows
+}
+
+// crashKind returns kind of the incident which starts from `entry`, or empty string.
+func crashKind(entry logcat.Entry, line string) string {
+	switch {
+	case entry["tag"] == "AndroidRuntime" && fatalRegexp.MatchString(line):
+		return crashJava
+	case tombstoneRegexp.MatchString(line):
+		return crashNative
+	case entry["tag"] == "ActivityManager" && anrRegexp.MatchString(line):
+		return crashANR
+	case entry["tag"] == "am_crash":
+		return crashEvent
+	}
+	return ""
+}
+
+// expire closes incidents, which have no lines since `crashBurst` before `now`.
+func (c *crashExtractor) expire(now time.Time) []logcat.Entry {
+	var rows []logcat.Entry
+	for i := 0; i < len(c.pending); {
+		if last := c.pending[i].last; !last.IsZero() && now.Sub(last) > crashBurst {
+			rows = append(rows, c.close(i))
+		} else {
+			i++
+		}
+	}
+	return rows
+}
+
+func (c *crashExtractor) find(key string) int {
+	for i, p := range c.pending {
+		if p.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// close removes the incident at `i`, and returns its row.
+func (c *crashExtractor) close(i int) logcat.Entry {
+	p := c.pending[i]
+	c.pending = append(c.pending[:i], c.pending[i+1:]...)
+
+	row := logcat.Entry{
+		"time":      p.entry["time"],
+		TraceColumn: strings.Join(p.lines, "\n"),
+	}
+	if zone, ok := p.entry[ZoneKey]; ok {
+		row[ZoneKey] = zone
+	}
+	switch p.kind {
+	case crashJava:
+		row["pid"] = p.entry["pid"]
+		row[ProcessColumn] = p.entry[ProcessColumn]
+		for _, line := range p.lines[1:] {
+			line = strings.TrimSpace(line)
+			if m := crashProcessRegexp.FindStringSubmatch(line); m != nil {
+				row[ProcessColumn], row["pid"] = m[1], m[2]
+			} else if m := frameRegexp.FindStringSubmatch(line); m != nil {
+				if row[FrameColumn] == "" {
+					row[FrameColumn] = m[1]
+				}
+			} else if m := exceptionRegexp.FindStringSubmatch(line); m != nil && row[ExceptionColumn] == "" {
+				row[ExceptionColumn] = m[1]
+			}
+		}
+	case crashNative:
+		for _, line := range p.lines {
+			line = strings.TrimSpace(line)
+			if m := tombstoneProcessRegexp.FindStringSubmatch(line); m != nil {
+				row["pid"], row[ProcessColumn] = m[1], m[2]
+			} else if m := signalRegexp.FindStringSubmatch(line); m != nil {
+				row[ExceptionColumn] = m[1]
+			} else if m := nativeFrameRegexp.FindStringSubmatch(line); m != nil && row[FrameColumn] == "" {
+				row[FrameColumn] = m[1]
+			}
+		}
+	case crashANR:
+		row[ExceptionColumn] = "ANR"
+		for _, line := range p.lines {
+			line = strings.TrimSpace(line)
+			if m := anrRegexp.FindStringSubmatch(line); m != nil {
+				row[ProcessColumn] = m[1]
+			} else if m := anrPidRegexp.FindStringSubmatch(line); m != nil {
+				row["pid"] = m[1]
+			} else if m := anrReasonRegexp.FindStringSubmatch(line); m != nil {
+				row[ExceptionColumn] = "ANR: " + m[1]
+			}
+		}
+	}
+	return row
+}
+
+// event returns a row of am_crash event, or nil if it is already reported by AndroidRuntime.
+func (c *crashExtractor) event(entry logcat.Entry) logcat.Entry {
+	// [User, PID, Process Name, Flags, Exception, Message, File, Line], User is omitted in old versions.
+	fields := eventFields(entry[Message])
+	if len(fields) >= 2 && isInt(fields[1]) {
+		fields = fields[1:]
+	}
+	if len(fields) < 7 || c.crashed[fields[0]] {
+		return nil
+	}
+	n := len(fields)
+	message := strings.Join(fields[4:n-2], ",") // message may contain commas.
+	row := logcat.Entry{
+		"time":          entry["time"],
+		"pid":           fields[0],
+		ProcessColumn:   fields[1],
+		ExceptionColumn: fields[3],
+		FrameColumn:     fields[n-2] + ":" + fields[n-1],
+		TraceColumn:     fields[3] + ": " + message,
+	}
+	if zone, ok := entry[ZoneKey]; ok {
+		row[ZoneKey] = zone
+	}
+	return row
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func extractCrashes(input string) []logcat.Entry {
+	c := newCrashExtractor()
+	var rows []logcat.Entry
+	for res := range newParser(nil).Entries(strings.NewReader(input), nil) {
+		rows = append(rows, c.Extract(res.entry)...)
+	}
+	return append(rows, c.Flush()...)
+}
+
+func TestCrashExtractor_Java(t *testing.T) {
+	input := "12-28 18:54:07.180  1234  1234 E AndroidRuntime: FATAL EXCEPTION: main\n" +
+		"12-28 18:54:07.180  1234  1234 E AndroidRuntime: Process: com.example.app, PID: 1234\n" +
+		"12-28 18:54:07.180   930   931 I ActivityManager: unrelated\n" +
+		"12-28 18:54:07.180  1234  1234 E AndroidRuntime: java.lang.NullPointerException: Attempt to invoke\n" +
+		"12-28 18:54:07.180  1234  1234 E AndroidRuntime: \tat com.example.app.Main.run(Main.java:10)\n" +
+		"12-28 18:54:07.180  1234  1234 E AndroidRuntime: \tat android.os.Handler.dispatchMessage(Handler.java:102)\n" +
+		"12-28 18:54:07.190  1234  1234 I am_crash: [0,1234,com.example.app,952745542,java.lang.NullPointerException,Attempt to invoke,Main.java,10]\n" +
+		"12-28 18:54:09.000  1234  1234 E AndroidRuntime: after the crash\n"
+	expect := []logcat.Entry{
+		{"time": "12-28 18:54:07.180", "pid": "1234", "process": "com.example.app",
+			"exception": "java.lang.NullPointerException", "frame": "com.example.app.Main.run(Main.java:10)",
+			"trace": "FATAL EXCEPTION: main\nProcess: com.example.app, PID: 1234\njava.lang.NullPointerException: Attempt to invoke\n" +
+				"\tat com.example.app.Main.run(Main.java:10)\n\tat android.os.Handler.dispatchMessage(Handler.java:102)"},
+	}
+
+	rows := extractCrashes(input)
+	if !reflect.DeepEqual(rows, expect) {
+		t.Errorf("\n  result: %q\n  expect: %q", rows, expect)
+	}
+}
+
+func TestCrashExtractor_Native(t *testing.T) {
+	input := "12-28 18:54:07.180  5678  5678 F DEBUG   : *** *** *** *** *** *** *** *** *** *** *** *** *** *** *** ***\n" +
+		"12-28 18:54:07.180  5678  5678 F DEBUG   : Build fingerprint: 'google/walleye/walleye:8.1.0'\n" +
+		"12-28 18:54:07.180  5678  5678 F DEBUG   : pid: 1234, tid: 1240, name: RenderThread  >>> com.example.app <<<\n" +
+		"12-28 18:54:07.180  5678  5678 F DEBUG   : signal 11 (SIGSEGV), code 1 (SEGV_MAPERR), fault addr 0x0\n" +
+		"12-28 18:54:07.181  5678  5678 F DEBUG   : backtrace:\n" +
+		"12-28 18:54:07.181  5678  5678 F DEBUG   :     #00 pc 000000000001d3f0  /system/lib64/libc.so (strlen+16)\n" +
+		"12-28 18:54:07.181  5678  5678 F DEBUG   :     #01 pc 0000000000012345  /data/app/lib/libfoo.so\n"
+	expect := []logcat.Entry{
+		{"time": "12-28 18:54:07.180", "pid": "1234", "process": "com.example.app",
+			"exception": "SIGSEGV", "frame": "/system/lib64/libc.so (strlen+16)",
+			"trace": "*** *** *** *** *** *** *** *** *** *** *** *** *** *** *** ***\nBuild fingerprint: 'google/walleye/walleye:8.1.0'\n" +
+				"pid: 1234, tid: 1240, name: RenderThread  >>> com.example.app <<<\nsignal 11 (SIGSEGV), code 1 (SEGV_MAPERR), fault addr 0x0\n" +
+				"backtrace:\n    #00 pc 000000000001d3f0  /system/lib64/libc.so (strlen+16)\n    #01 pc 0000000000012345  /data/app/lib/libfoo.so"},
+	}
+
+	rows := extractCrashes(input)
+	if !reflect.DeepEqual(rows, expect) {
+		t.Errorf("\n  result: %q\n  expect: %q", rows, expect)
+	}
+}
+
+func TestCrashExtractor_ANR(t *testing.T) {
+	input := "12-28 18:54:07.180   930   950 E ActivityManager: ANR in com.example.app (com.example.app/.MainActivity)\n" +
+		"12-28 18:54:07.180   930   950 E ActivityManager: PID: 1234\n" +
+		"12-28 18:54:07.180   930   950 E ActivityManager: Reason: Input dispatching timed out\n" +
+		"12-28 18:54:10.000   930   950 I ActivityManager: unrelated\n"
+	expect := []logcat.Entry{
+		{"time": "12-28 18:54:07.180", "pid": "1234", "process": "com.example.app",
+			"exception": "ANR: Input dispatching timed out",
+			"trace":     "ANR in com.example.app (com.example.app/.MainActivity)\nPID: 1234\nReason: Input dispatching timed out"},
+	}
+
+	rows := extractCrashes(input)
+	if !reflect.DeepEqual(rows, expect) {
+		t.Errorf("\n  result: %q\n  expect: %q", rows, expect)
+	}
+}
+
+func TestCrashExtractor_Event(t *testing.T) {
+	input := "12-28 18:54:07.190  930  950 I am_crash: [1234,com.example.app,952745542,java.lang.IllegalStateException,a, b,Main.java,10]\n"
+	expect := []logcat.Entry{
+		{"time": "12-28 18:54:07.190", "pid": "1234", "process": "com.example.app",
+			"exception": "java.lang.IllegalStateException", "frame": "Main.java:10",
+			"trace": "java.lang.IllegalStateException: a, b"},
+	}
+
+	rows := extractCrashes(input)
+	if !reflect.DeepEqual(rows, expect) {
+		t.Errorf("\n  result: %q\n  expect: %q", rows, expect)
+	}
+}
+
+func TestCrashExtractor_Rollover(t *testing.T) {
+	input := "12-31 23:59:59.500  1234  1234 E AndroidRuntime: FATAL EXCEPTION: main\n" +
+		"12-31 23:59:59.500  1234  1234 E AndroidRuntime: java.lang.IllegalStateException\n" +
+		"01-01 00:00:05.000  1234  1234 E AndroidRuntime: after the crash\n"
+	expect := []logcat.Entry{
+		{"time": "12-31 23:59:59.500", "pid": "1234", "process": "",
+			"exception": "java.lang.IllegalStateException",
+			"trace":     "FATAL EXCEPTION: main\njava.lang.IllegalStateException"},
+	}
+
+	rows := extractCrashes(input)
+	if !reflect.DeepEqual(rows, expect) {
+		t.Errorf("\n  result: %q\n  expect: %q", rows, expect)
+	}
+}
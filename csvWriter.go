@@ -28,21 +28,34 @@ const (
 	Message = "message"
 )
 
+// Columns represents the default schema of output rows.
+var Columns = []string{"time", "pid", "tid", "priority", "tag", Message}
+
+// DerivedColumns represents optional columns, which are computed while converting.
+var DerivedColumns = []string{ProcessColumn, ElapsedColumn, DeltaColumn, PidDeltaColumn}
+
 // CsvWriter is wrapper of csv.Writer to writing logcat.Entry.
 type CsvWriter struct {
 	encodedWriter *csv.Writer
 	writer        *csv.Writer
 	encoder       io.Writer
 	buff          *bytes.Buffer
+	columns       []string
 }
 
 // NewWriter creates new csvWriter.
-func NewWriter(w io.Writer, encode string, osName string) *CsvWriter {
+// If `columns` is empty, Columns is used.
+// If `comma` is 0, fields are delimited by ','.
+func NewWriter(w io.Writer, encode string, osName string, columns []string, comma rune) *CsvWriter {
 	if osName == Windows && encode == "" {
 		encode = ShiftJIS
 	}
+	if len(columns) == 0 {
+		columns = Columns
+	}
 	res := &CsvWriter{
 		encodedWriter: csv.NewWriter(generateEncoder(w, encode)),
+		columns:       columns,
 	}
 
 	// for fail-safe of encoding.
@@ -53,9 +66,16 @@ func NewWriter(w io.Writer, encode string, osName string) *CsvWriter {
 		res.buff = buff
 	}
 
+	if comma != 0 {
+		res.encodedWriter.Comma = comma
+		if res.writer != nil {
+			res.writer.Comma = comma
+		}
+	}
 	if osName == Windows {
 		res.encodedWriter.UseCRLF = true
 	}
+	res.encodedWriter.Write(columns)
 	return res
 }
 
@@ -65,13 +85,18 @@ func (f *CsvWriter) Write(item logcat.Entry) (err error) {
 		return nil
 	}
 
-	err = f.canEncode(item[Message])
+	values := f.values(item)
+	for _, value := range values {
+		if err = f.canEncode(value); err != nil {
+			break
+		}
+	}
 	if err == nil {
-		f.encodedWriter.Write(item.Values())
+		f.encodedWriter.Write(values)
 	} else {
-		// If the message can't be encoded, output with UTF8.
+		// If any field can't be encoded, output with UTF8.
 		f.encodedWriter.Flush()
-		f.writer.Write(item.Values())
+		f.writer.Write(values)
 		f.writer.Flush()
 	}
 
@@ -79,8 +104,19 @@ func (f *CsvWriter) Write(item logcat.Entry) (err error) {
 }
 
 // Flush flushes buffer to file.
-func (f *CsvWriter) Flush() {
+func (f *CsvWriter) Flush() error {
 	f.encodedWriter.Flush()
+	return f.encodedWriter.Error()
+}
+
+// values returns fields of `item` ordered by columns.
+// Missing fields are padded with empty string.
+func (f *CsvWriter) values(item logcat.Entry) []string {
+	values := make([]string, len(f.columns))
+	for i, key := range f.columns {
+		values[i] = item[key]
+	}
+	return values
 }
 
 // Check the `str` can be encoded.
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/ujiro99/logcatf/logcat"
@@ -17,10 +18,10 @@ func TestCsvWriter_Write_Full(t *testing.T) {
 		"tag":      "auditd",
 		"message":  "  test Message",
 	}
-	expected := "12-28 18:54:07.180,930,931,I,auditd,\"  test Message\"\n"
+	expected := header + "\n" + "12-28 18:54:07.180,930,931,I,auditd,\"  test Message\"\n"
 
 	writer := new(bytes.Buffer)
-	csvWriter := NewWriter(writer, "", "")
+	csvWriter := NewWriter(writer, "", "", nil, 0)
 	csvWriter.Write(entry)
 	csvWriter.Flush()
 
@@ -36,10 +37,10 @@ func TestCsvWriter_Write(t *testing.T) {
 		"message": "  test Message",
 		"tag":     "auditd",
 	}
-	expected := "12-28 18:54:07.180,auditd,\"  test Message\"\n"
+	expected := header + "\n" + "12-28 18:54:07.180,,,,auditd,\"  test Message\"\n"
 
 	writer := new(bytes.Buffer)
-	csvWriter := NewWriter(writer, "", "")
+	csvWriter := NewWriter(writer, "", "", nil, 0)
 	csvWriter.Write(entry)
 	csvWriter.Flush()
 
@@ -55,10 +56,10 @@ func TestCsvWriter_Write_Windows(t *testing.T) {
 		"message": "test Message:漢字",
 		"tag":     "auditd",
 	}
-	expected := convertTo("12-28 18:54:07.180,auditd,test Message:漢字\r\n", ShiftJIS)
+	expected := convertTo(header+"\r\n"+"12-28 18:54:07.180,,,,auditd,test Message:漢字\r\n", ShiftJIS)
 
 	writer := new(bytes.Buffer)
-	csvWriter := NewWriter(writer, "", "windows")
+	csvWriter := NewWriter(writer, "", "windows", nil, 0)
 	csvWriter.Write(entry)
 	csvWriter.Flush()
 
@@ -74,11 +75,11 @@ func TestCsvWriter_Write_Encode(t *testing.T) {
 		"message": "test Message:あ亜Ａア￥凜熙♪堯",
 		"tag":     "auditd",
 	}
-	expected := ("12-28 18:54:07.180,auditd,test Message:あ亜Ａア￥凜熙♪堯\n")
+	expected := header + "\n" + "12-28 18:54:07.180,,,,auditd,test Message:あ亜Ａア￥凜熙♪堯\n"
 
 	for _, encode := range []string{UTF8, ShiftJIS, EUCJP, ISO2022JP} {
 		writer := new(bytes.Buffer)
-		csvWriter := NewWriter(writer, encode, "")
+		csvWriter := NewWriter(writer, encode, "", nil, 0)
 		csvWriter.Write(entry)
 		csvWriter.Flush()
 
@@ -88,13 +89,86 @@ func TestCsvWriter_Write_Encode(t *testing.T) {
 	}
 }
 
+func TestCsvWriter_Write_Mixed_Format(t *testing.T) {
+
+	entries := []logcat.Entry{
+		{"time": "12-28 18:54:07.180", "pid": "930", "tid": "931", "priority": "I", "tag": "auditd", "message": "threadtime"},
+		{"time": "12-28 18:54:07.180", "pid": "930", "priority": "I", "tag": "auditd", "message": "time"},
+		{"pid": "930", "priority": "I", "tag": "auditd", "message": "brief"},
+		{"message": "raw"},
+	}
+	expected := header + "\n" +
+		"12-28 18:54:07.180,930,931,I,auditd,threadtime\n" +
+		"12-28 18:54:07.180,930,,I,auditd,time\n" +
+		",930,,I,auditd,brief\n" +
+		",,,,,raw\n"
+
+	writer := new(bytes.Buffer)
+	csvWriter := NewWriter(writer, "", "", nil, 0)
+	for _, entry := range entries {
+		csvWriter.Write(entry)
+	}
+	csvWriter.Flush()
+
+	if !(writer.String() == expected) {
+		t.Errorf("expected %q to eq %q", writer.String(), expected)
+	}
+}
+
+func TestCsvWriter_Write_Columns(t *testing.T) {
+
+	entry := logcat.Entry{
+		"time":     "12-28 18:54:07.180",
+		"pid":      "930",
+		"tid":      "931",
+		"priority": "I",
+		"tag":      "auditd",
+		"message":  "test Message",
+	}
+	expected := "message,time,priority\n" + "test Message,12-28 18:54:07.180,I\n"
+
+	writer := new(bytes.Buffer)
+	csvWriter := NewWriter(writer, "", "", []string{"message", "time", "priority"}, 0)
+	csvWriter.Write(entry)
+	csvWriter.Flush()
+
+	if !(writer.String() == expected) {
+		t.Errorf("expected %q to eq %q", writer.String(), expected)
+	}
+}
+
+func TestCsvWriter_Write_Delimiter(t *testing.T) {
+
+	entry := logcat.Entry{
+		"time":    "12-28 18:54:07.180",
+		"message": "test, Message; with\tdelimiters",
+		"tag":     "auditd",
+	}
+
+	for comma, expected := range map[rune]string{
+		'\t': "12-28 18:54:07.180\t\t\t\tauditd\t\"test, Message; with\tdelimiters\"\n",
+		';':  "12-28 18:54:07.180;;;;auditd;\"test, Message; with\tdelimiters\"\n",
+		'|':  "12-28 18:54:07.180||||auditd|test, Message; with\tdelimiters\n",
+	} {
+		writer := new(bytes.Buffer)
+		csvWriter := NewWriter(writer, "", "", []string{"time", "pid", "tid", "priority", "tag", "message"}, comma)
+		csvWriter.Write(entry)
+		csvWriter.Flush()
+
+		header := strings.Join(Columns, string(comma)) + "\n"
+		if !(writer.String() == header+expected) {
+			t.Errorf("expected %q to eq %q", writer.String(), header+expected)
+		}
+	}
+}
+
 func TestCsvWriter_Empty(t *testing.T) {
 
 	entry := logcat.Entry{}
-	expected := "\n"
+	expected := header + "\n" + ",,,,,\n"
 
 	writer := new(bytes.Buffer)
-	csvWriter := NewWriter(writer, "", "")
+	csvWriter := NewWriter(writer, "", "", nil, 0)
 	csvWriter.Write(entry)
 	csvWriter.Flush()
 
@@ -105,10 +179,10 @@ func TestCsvWriter_Empty(t *testing.T) {
 
 func TestCsvWriter_Nil(t *testing.T) {
 
-	expected := ""
+	expected := header + "\n"
 
 	writer := new(bytes.Buffer)
-	csvWriter := NewWriter(writer, "", "")
+	csvWriter := NewWriter(writer, "", "", nil, 0)
 	csvWriter.Write(nil)
 	csvWriter.Flush()
 
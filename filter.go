@@ -0,0 +1,298 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+// Priorities represents priorities of logcat, in ascending order.
+// `S` (Silent) is only used in filterspecs.
+const Priorities = "VDIWEFS"
+
+// Filter is the interface that decides whether a logcat.Entry is written or not.
+type Filter interface {
+	Match(item logcat.Entry) bool
+}
+
+// resetter is implemented by stateful filters, to reset state for each input.
+type resetter interface {
+	Reset()
+}
+
+// filters matches a logcat.Entry if all of the filters match.
+type filters []Filter
+
+// Match returns true if `item` matches all filters.
+// All filters see every entry, because some filters are stateful.
+func (fs filters) Match(item logcat.Entry) bool {
+	match := true
+	for _, f := range fs {
+		if !f.Match(item) {
+			match = false
+		}
+	}
+	return match
+}
+
+// Reset resets states of filters.
+func (fs filters) Reset() {
+	for _, f := range fs {
+		if r, ok := f.(resetter); ok {
+			r.Reset()
+		}
+	}
+}
+
+// priorityLevel returns level of the priority, as index of Priorities.
+// `A` (Assert) is treated as same as `F`. Unknown priority is treated as `V`.
+func priorityLevel(priority string) int {
+	if priority == "A" {
+		priority = "F"
+	}
+	if len(priority) != 1 {
+		return 0
+	}
+	if i := strings.Index(Priorities, priority); i >= 0 {
+		return i
+	}
+	return 0
+}
+
+// priorityFilter filters entries which priority is lower than the level.
+type priorityFilter struct {
+	level int
+}
+
+// newPriorityFilter creates Filter which drops entries below the `priority`.
+func newPriorityFilter(priority string) (Filter, error) {
+	priority = strings.ToUpper(priority)
+	if len(priority) != 1 || !strings.Contains("VDIWEFA", priority) {
+		return nil, fmt.Errorf("Invalid priority: %s", priority)
+	}
+	return &priorityFilter{level: priorityLevel(priority)}, nil
+}
+
+// Match returns true if the priority of `item` is equal or higher than the level.
+func (f *priorityFilter) Match(item logcat.Entry) bool {
+	return priorityLevel(item["priority"]) >= f.level
+}
+
+// regexpFilter filters entries by a regular expression on a field.
+type regexpFilter struct {
+	key    string
+	re     *regexp.Regexp
+	invert bool
+}
+
+// newRegexpFilter creates Filter which matches if the field `key` matches `expr`.
+// If `invert` is true, it matches if the field doesn't match.
+func newRegexpFilter(key, expr string, invert bool) (Filter, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid regexp: %s", err)
+	}
+	return &regexpFilter{key: key, re: re, invert: invert}, nil
+}
+
+// Match returns true if the field of `item` matches the regular expression.
+func (f *regexpFilter) Match(item logcat.Entry) bool {
+	return f.re.MatchString(item[f.key]) != f.invert
+}
+
+// timeBound represents a bound of time range.
+// If `relative` is true, it is an offset from the first entry.
+// Otherwise, the year is resolved from the first entry.
+type timeBound struct {
+	time     time.Time
+	offset   time.Duration
+	relative bool
+}
+
+// parseTimeBound parses `MM-DD HH:MM:SS[.mmm]`, or an offset like `+5m`.
+func parseTimeBound(str string) (*timeBound, error) {
+	if strings.HasPrefix(str, "+") {
+		d, err := time.ParseDuration(str[1:])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid time: %s", str)
+		}
+		return &timeBound{offset: d, relative: true}, nil
+	}
+	t, err := time.Parse(logcatTimeLayout, str)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid time: %s", str)
+	}
+	return &timeBound{time: t}, nil
+}
+
+func (b *timeBound) resolve(base time.Time) time.Time {
+	if b.relative {
+		return base.Add(b.offset)
+	}
+	// The year closest to the first entry, e.g. `01-01` is in the next year of the log started on Dec 31.
+	t := withYear(b.time, base.Year())
+	for _, year := range []int{base.Year() - 1, base.Year() + 1} {
+		if c := withYear(b.time, year); absDuration(c.Sub(base)) < absDuration(t.Sub(base)) {
+			t = c
+		}
+	}
+	return t
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// timeFilter filters entries out of the time range.
+// Both of bounds are inclusive, and nil bound means unbounded.
+type timeFilter struct {
+	since, until *timeBound
+	base         *time.Time
+	years        *yearResolver
+}
+
+// newTimeFilter creates Filter which matches entries within `since` and `until`.
+// Empty string means unbounded.
+func newTimeFilter(since, until string) (Filter, error) {
+	res := &timeFilter{years: newRolloverResolver()}
+	var err error
+	if since != "" {
+		if res.since, err = parseTimeBound(since); err != nil {
+			return nil, err
+		}
+	}
+	if until != "" {
+		if res.until, err = parseTimeBound(until); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// Reset forgets the time of the first entry.
+func (f *timeFilter) Reset() {
+	f.base = nil
+	f.years = newRolloverResolver()
+}
+
+// Match returns true if the time of `item` is within the range.
+// Entries without time never match.
+func (f *timeFilter) Match(item logcat.Entry) bool {
+	t, err := resolveTime(f.years, item["time"])
+	if err != nil {
+		return false
+	}
+	if f.base == nil {
+		f.base = &t // relative bounds are based on the first entry.
+	}
+	if f.since != nil && t.Before(f.since.resolve(*f.base)) {
+		return false
+	}
+	if f.until != nil && t.After(f.until.resolve(*f.base)) {
+		return false
+	}
+	return true
+}
+
+// valuesFilter filters entries by a field, which is one of values.
+type valuesFilter struct {
+	key    string
+	values map[string]bool
+}
+
+// newValuesFilter creates Filter which matches if the field `key` is one of `values`.
+func newValuesFilter(key string, values []string) Filter {
+	res := &valuesFilter{key: key, values: map[string]bool{}}
+	for _, v := range values {
+		res.values[v] = true
+	}
+	return res
+}
+
+// Match returns true if the field of `item` is one of values.
+func (f *valuesFilter) Match(item logcat.Entry) bool {
+	return f.values[item[f.key]]
+}
+
+// processFilter filters entries by process names.
+// Process names are inferred from ActivityManager events, so restarts are followed.
+type processFilter struct {
+	names     map[string]bool
+	processes *processTracker
+}
+
+// newProcessFilter creates Filter which matches entries of the processes.
+func newProcessFilter(names []string) Filter {
+	res := &processFilter{names: map[string]bool{}, processes: newProcessTracker()}
+	for _, name := range names {
+		res.names[name] = true
+	}
+	return res
+}
+
+// Reset forgets all processes.
+func (f *processFilter) Reset() {
+	f.processes.Reset()
+}
+
+// Match returns true if `item` is logged by one of the processes.
+func (f *processFilter) Match(item logcat.Entry) bool {
+	f.processes.Track(item)
+	return f.names[f.processes.Name(item["pid"])]
+}
+
+var filterSpecRegexp = regexp.MustCompile(`^[^:\s]+:[VDIWEFSA]$`)
+
+// specFilter filters entries by filterspecs of `adb logcat`, like `ActivityManager:I *:S`.
+type specFilter struct {
+	levels       map[string]int
+	defaultLevel int
+}
+
+// newSpecFilter creates Filter from filterspecs `TAG:PRIORITY`.
+// Entries which tag isn't specified are filtered by `*:PRIORITY`, default is `*:V`.
+func newSpecFilter(specs []string) (Filter, error) {
+	res := &specFilter{levels: map[string]int{}}
+	for _, spec := range specs {
+		if !filterSpecRegexp.MatchString(spec) {
+			return nil, fmt.Errorf("Invalid filterspec: %s", spec)
+		}
+		i := strings.LastIndex(spec, ":")
+		tag, level := spec[:i], priorityLevel(spec[i+1:])
+		if tag == "*" {
+			res.defaultLevel = level
+		} else {
+			res.levels[tag] = level
+		}
+	}
+	return res, nil
+}
+
+// Match returns true if the priority of `item` is equal or higher than the spec of the tag.
+func (f *specFilter) Match(item logcat.Entry) bool {
+	level, ok := f.levels[item["tag"]]
+	if !ok {
+		level = f.defaultLevel
+	}
+	return level < len(Priorities)-1 && priorityLevel(item["priority"]) >= level
+}
+
+// splitFilterSpecs separates filterspecs from arguments.
+// An argument which looks like filterspec, and doesn't exist as a file is a filterspec.
+func splitFilterSpecs(args []string) (paths, specs []string) {
+	for _, arg := range args {
+		if _, err := os.Stat(arg); err != nil && filterSpecRegexp.MatchString(arg) {
+			specs = append(specs, arg)
+		} else {
+			paths = append(paths, arg)
+		}
+	}
+	return paths, specs
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func TestSpecFilter_Match(t *testing.T) {
+	filter, err := newSpecFilter([]string{"ActivityManager:I", "MyApp:V", "*:S"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		entry  logcat.Entry
+		expect bool
+	}{
+		{logcat.Entry{"tag": "ActivityManager", "priority": "D"}, false},
+		{logcat.Entry{"tag": "ActivityManager", "priority": "I"}, true},
+		{logcat.Entry{"tag": "ActivityManager", "priority": "E"}, true},
+		{logcat.Entry{"tag": "MyApp", "priority": "V"}, true},
+		{logcat.Entry{"tag": "Other", "priority": "F"}, false},
+		{logcat.Entry{"message": "--------- beginning of main"}, false},
+	}
+	for _, test := range tests {
+		if res := filter.Match(test.entry); res != test.expect {
+			t.Errorf("%v: expected %v to eq %v", test.entry, res, test.expect)
+		}
+	}
+}
+
+func TestSpecFilter_Match_Default(t *testing.T) {
+	filter, err := newSpecFilter([]string{"chatty:S", "*:W"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		entry  logcat.Entry
+		expect bool
+	}{
+		{logcat.Entry{"tag": "chatty", "priority": "E"}, false},
+		{logcat.Entry{"tag": "auditd", "priority": "I"}, false},
+		{logcat.Entry{"tag": "auditd", "priority": "W"}, true},
+		{logcat.Entry{"tag": "auditd", "priority": "A"}, true},
+	}
+	for _, test := range tests {
+		if res := filter.Match(test.entry); res != test.expect {
+			t.Errorf("%v: expected %v to eq %v", test.entry, res, test.expect)
+		}
+	}
+}
+
+func TestSpecFilter_Invalid(t *testing.T) {
+	if _, err := newSpecFilter([]string{"MyApp:X"}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestPriorityFilter_Match(t *testing.T) {
+	filter, err := newPriorityFilter("w")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		entry  logcat.Entry
+		expect bool
+	}{
+		{logcat.Entry{"priority": "V"}, false},
+		{logcat.Entry{"priority": "I"}, false},
+		{logcat.Entry{"priority": "W"}, true},
+		{logcat.Entry{"priority": "E"}, true},
+		{logcat.Entry{"priority": "A"}, true},
+		{logcat.Entry{"message": "--------- beginning of main"}, false},
+	}
+	for _, test := range tests {
+		if res := filter.Match(test.entry); res != test.expect {
+			t.Errorf("%v: expected %v to eq %v", test.entry, res, test.expect)
+		}
+	}
+}
+
+func TestPriorityFilter_Invalid(t *testing.T) {
+	for _, p := range []string{"", "S", "X", "DI"} {
+		if _, err := newPriorityFilter(p); err == nil {
+			t.Errorf("%q: expected error", p)
+		}
+	}
+}
+
+func TestRegexpFilter_Match(t *testing.T) {
+	grep, _ := newRegexpFilter("message", "FATAL|ANR|Exception", false)
+	grepV, _ := newRegexpFilter("message", "^at ", true)
+	tagRegexp, _ := newRegexpFilter("tag", "^Activity", false)
+
+	tests := []struct {
+		filter Filter
+		entry  logcat.Entry
+		expect bool
+	}{
+		{grep, logcat.Entry{"message": "FATAL EXCEPTION: main"}, true},
+		{grep, logcat.Entry{"message": "java.lang.NullPointerException"}, true},
+		{grep, logcat.Entry{"message": "Start proc"}, false},
+		{grepV, logcat.Entry{"message": "at com.foo.Bar.baz(Bar.java:10)"}, false},
+		{grepV, logcat.Entry{"message": "Caused by: java.lang.Error"}, true},
+		{tagRegexp, logcat.Entry{"tag": "ActivityManager"}, true},
+		{tagRegexp, logcat.Entry{"tag": "MyActivity"}, false},
+		{tagRegexp, logcat.Entry{"message": "raw"}, false},
+	}
+	for _, test := range tests {
+		if res := test.filter.Match(test.entry); res != test.expect {
+			t.Errorf("%v: expected %v to eq %v", test.entry, res, test.expect)
+		}
+	}
+}
+
+func TestRegexpFilter_Invalid(t *testing.T) {
+	if _, err := newRegexpFilter("message", "(", false); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestTimeFilter_Match(t *testing.T) {
+	filter, err := newTimeFilter("12-28 18:54:00", "12-28 19:00:00.500")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		entry  logcat.Entry
+		expect bool
+	}{
+		{logcat.Entry{"time": "12-28 18:53:59.999"}, false},
+		{logcat.Entry{"time": "12-28 18:54:00.000"}, true},
+		{logcat.Entry{"time": "12-28 19:00:00.500"}, true},
+		{logcat.Entry{"time": "12-28 19:00:00.501"}, false},
+		{logcat.Entry{"message": "--------- beginning of main"}, false},
+	}
+	for _, test := range tests {
+		if res := filter.Match(test.entry); res != test.expect {
+			t.Errorf("%v: expected %v to eq %v", test.entry, res, test.expect)
+		}
+	}
+}
+
+func TestTimeFilter_Match_Rollover(t *testing.T) {
+	tests := []struct {
+		since, until string
+		entries      []string
+		expect       []bool
+	}{
+		{"12-31 23:00:00", "01-01 01:00:00",
+			[]string{"12-31 22:00:00.000", "12-31 23:30:00.000", "01-01 00:30:00.000", "01-01 02:00:00.000"},
+			[]bool{false, true, true, false}},
+		{"12-31 23:00:00", "",
+			[]string{"12-31 22:00:00.000", "01-01 00:30:00.000", "01-02 00:30:00.000"},
+			[]bool{false, true, true}},
+		{"12-31 23:00:00", "",
+			[]string{"01-01 00:30:00.000", "01-01 02:00:00.000"},
+			[]bool{true, true}},
+	}
+	for _, test := range tests {
+		filter, err := newTimeFilter(test.since, test.until)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, str := range test.entries {
+			if res := filter.Match(logcat.Entry{"time": str}); res != test.expect[i] {
+				t.Errorf("%s: expected %v to eq %v", str, res, test.expect[i])
+			}
+		}
+	}
+}
+
+func TestTimeFilter_Match_Relative(t *testing.T) {
+	filter, err := newTimeFilter("+1m", "+90s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		entry  logcat.Entry
+		expect bool
+	}{
+		{logcat.Entry{"time": "12-28 18:54:00.000"}, false},
+		{logcat.Entry{"time": "12-28 18:54:59.999"}, false},
+		{logcat.Entry{"time": "12-28 18:55:00.000"}, true},
+		{logcat.Entry{"time": "12-28 18:55:30.000"}, true},
+		{logcat.Entry{"time": "12-28 18:55:30.001"}, false},
+	}
+	for _, test := range tests {
+		if res := filter.Match(test.entry); res != test.expect {
+			t.Errorf("%v: expected %v to eq %v", test.entry, res, test.expect)
+		}
+	}
+}
+
+func TestTimeFilter_Invalid(t *testing.T) {
+	for _, str := range []string{"18:54:00", "+5", "2017-12-28", "2017-12-31T23:59:59", "1514732399.000"} {
+		if _, err := newTimeFilter(str, ""); err == nil {
+			t.Errorf("%q: expected error", str)
+		}
+	}
+}
+
+func TestValuesFilter_Match(t *testing.T) {
+	filter := newValuesFilter("pid", []string{"930", "1"})
+
+	tests := []struct {
+		entry  logcat.Entry
+		expect bool
+	}{
+		{logcat.Entry{"pid": "930"}, true},
+		{logcat.Entry{"pid": "1"}, true},
+		{logcat.Entry{"pid": "9300"}, false},
+		{logcat.Entry{"message": "raw"}, false},
+	}
+	for _, test := range tests {
+		if res := filter.Match(test.entry); res != test.expect {
+			t.Errorf("%v: expected %v to eq %v", test.entry, res, test.expect)
+		}
+	}
+}
+
+func TestProcessFilter_Match(t *testing.T) {
+	filter := newProcessFilter([]string{"com.example.app"})
+
+	tests := []struct {
+		entry  logcat.Entry
+		expect bool
+	}{
+		{logcat.Entry{"pid": "2000", "tag": "MyApp"}, false},
+		{logcat.Entry{"pid": "500", "tag": "ActivityManager", "message": "Start proc 2000:com.example.app/u0a123 for activity {com.example.app/.Main}"}, false},
+		{logcat.Entry{"pid": "2000", "tag": "MyApp"}, true},
+		{logcat.Entry{"pid": "2001", "tag": "MyApp"}, false},
+		// restarted with old format.
+		{logcat.Entry{"pid": "500", "tag": "ActivityManager", "message": "Start proc com.example.app for service com.example.app/.Sync: pid=3000 uid=10123 gids={50123}"}, false},
+		{logcat.Entry{"pid": "3000", "tag": "MyApp"}, true},
+		{logcat.Entry{"pid": "500", "tag": "ActivityManager", "message": "Start proc 2000:com.other/u0a124 for activity {com.other/.Main}"}, false},
+		{logcat.Entry{"pid": "2000", "tag": "Other"}, false},
+	}
+	for _, test := range tests {
+		if res := filter.Match(test.entry); res != test.expect {
+			t.Errorf("%v: expected %v to eq %v", test.entry, res, test.expect)
+		}
+	}
+}
+
+func TestSplitFilterSpecs(t *testing.T) {
+	paths, specs := splitFilterSpecs([]string{"test/logcat.txt", "MyApp:D", "*:S", "test"})
+
+	if expect := []string{"test/logcat.txt", "test"}; !reflect.DeepEqual(paths, expect) {
+		t.Errorf("expected %v to eq %v", paths, expect)
+	}
+	if expect := []string{"MyApp:D", "*:S"}; !reflect.DeepEqual(specs, expect) {
+		t.Errorf("expected %v to eq %v", specs, expect)
+	}
+}
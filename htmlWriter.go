@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+// HTMLWriter writes logcat.Entry as a self-contained HTML page.
+type HTMLWriter struct {
+	writer  *bufio.Writer
+	columns []string
+}
+
+// NewHTMLWriter creates new HTMLWriter.
+// If `columns` is empty, Columns is used.
+func NewHTMLWriter(w io.Writer, columns []string) *HTMLWriter {
+	if len(columns) == 0 {
+		columns = Columns
+	}
+	res := &HTMLWriter{
+		writer:  bufio.NewWriter(w),
+		columns: columns,
+	}
+	res.writer.WriteString(htmlHeader)
+	res.writer.WriteString("<thead><tr>")
+	for _, col := range columns {
+		fmt.Fprintf(res.writer, `<th data-col="%s">%s</th>`, html.EscapeString(col), html.EscapeString(col))
+	}
+	res.writer.WriteString("</tr></thead>\n<tbody>\n")
+	return res
+}
+
+// Write writes logcat.Entry as a table row.
+func (f *HTMLWriter) Write(item logcat.Entry) error {
+	if item == nil {
+		return nil
+	}
+
+	fmt.Fprintf(f.writer, `<tr class="p-%s">`, html.EscapeString(item["priority"]))
+	for _, key := range f.columns {
+		fmt.Fprintf(f.writer, "<td>%s</td>", html.EscapeString(item[key]))
+	}
+	f.writer.WriteString("</tr>\n")
+	return nil
+}
+
+// Flush finishes the page, and flushes it to file.
+func (f *HTMLWriter) Flush() error {
+	f.writer.WriteString(htmlFooter)
+	return f.writer.Flush()
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>logcat</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; margin: 0; }
+#filter { position: sticky; top: 0; padding: 8px; background: #eee; border-bottom: 1px solid #ccc; }
+#filter input { margin-right: 12px; }
+table { border-collapse: collapse; width: 100%; }
+th { position: sticky; top: 41px; background: #ddd; cursor: pointer; text-align: left; }
+th, td { padding: 2px 6px; border-bottom: 1px solid #eee; vertical-align: top; }
+td { font-family: monospace; white-space: pre-wrap; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+tr.p-V, tr.p-D { color: #666; }
+tr.p-W { background: #fff6d5; }
+tr.p-E, tr.p-F, tr.p-A { background: #ffd9d9; }
+</style>
+</head>
+<body>
+<div id="filter">
+tag <input id="f-tag" type="search">
+pid <input id="f-pid" type="search" size="8">
+text <input id="f-text" type="search" size="40">
+<span id="count"></span>
+</div>
+<table id="log">
+`
+
+const htmlFooter = `</tbody>
+</table>
+<script>
+(function() {
+  var table = document.getElementById("log");
+  var tbody = table.tBodies[0];
+  var rows = Array.prototype.slice.call(tbody.rows);
+  var headers = Array.prototype.slice.call(table.tHead.rows[0].cells);
+  var index = {};
+  headers.forEach(function(th, i) { index[th.getAttribute("data-col")] = i; });
+
+  function cell(row, col) {
+    var i = index[col];
+    return i === undefined ? "" : row.cells[i].textContent;
+  }
+
+  function filter() {
+    var tag = document.getElementById("f-tag").value.toLowerCase();
+    var pid = document.getElementById("f-pid").value;
+    var text = document.getElementById("f-text").value.toLowerCase();
+    var shown = 0;
+    rows.forEach(function(row) {
+      var match = (!tag || cell(row, "tag").toLowerCase().indexOf(tag) >= 0) &&
+        (!pid || cell(row, "pid") === pid) &&
+        (!text || row.textContent.toLowerCase().indexOf(text) >= 0);
+      row.style.display = match ? "" : "none";
+      if (match) shown++;
+    });
+    document.getElementById("count").textContent = shown + " / " + rows.length;
+  }
+
+  headers.forEach(function(th, i) {
+    th.addEventListener("click", function() {
+      var asc = !th.classList.contains("asc");
+      headers.forEach(function(h) { h.classList.remove("asc", "desc"); });
+      th.classList.add(asc ? "asc" : "desc");
+      var numeric = rows.every(function(row) { return !isNaN(Number(row.cells[i].textContent)); });
+      rows.sort(function(a, b) {
+        var x = a.cells[i].textContent, y = b.cells[i].textContent;
+        var r = numeric ? Number(x) - Number(y) : (x < y ? -1 : x > y ? 1 : 0);
+        return asc ? r : -r;
+      });
+      rows.forEach(function(row) { tbody.appendChild(row); });
+    });
+  });
+
+  ["f-tag", "f-pid", "f-text"].forEach(function(id) {
+    document.getElementById(id).addEventListener("input", filter);
+  });
+  filter();
+})();
+</script>
+</body>
+</html>
+`
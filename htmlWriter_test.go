@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func TestHTMLWriter_Write(t *testing.T) {
+
+	entry := logcat.Entry{
+		"time":     "12-28 18:54:07.180",
+		"pid":      "930",
+		"priority": "E",
+		"tag":      "auditd",
+		"message":  "<script>alert(\"&\")</script>",
+	}
+	expected := []string{
+		`<thead><tr><th data-col="time">time</th><th data-col="pid">pid</th>`,
+		`<tr class="p-E"><td>12-28 18:54:07.180</td><td>930</td><td></td><td>E</td><td>auditd</td>` +
+			`<td>&lt;script&gt;alert(&#34;&amp;&#34;)&lt;/script&gt;</td></tr>`,
+		"</html>\n",
+	}
+
+	writer := new(bytes.Buffer)
+	htmlWriter := NewHTMLWriter(writer, nil)
+	htmlWriter.Write(entry)
+	htmlWriter.Flush()
+
+	for _, e := range expected {
+		if !strings.Contains(writer.String(), e) {
+			t.Errorf("expected %q to contain %q", writer.String(), e)
+		}
+	}
+}
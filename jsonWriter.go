@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+// JSONWriter writes logcat.Entry as JSON Lines.
+type JSONWriter struct {
+	writer  *bufio.Writer
+	columns []string
+}
+
+// NewJSONWriter creates new JSONWriter.
+// If `columns` is empty, Columns is used.
+func NewJSONWriter(w io.Writer, columns []string) *JSONWriter {
+	if len(columns) == 0 {
+		columns = Columns
+	}
+	return &JSONWriter{
+		writer:  bufio.NewWriter(w),
+		columns: columns,
+	}
+}
+
+// Write writes logcat.Entry as a JSON object, keyed by column name.
+// Keys are ordered by columns, and missing fields are omitted.
+func (f *JSONWriter) Write(item logcat.Entry) error {
+	if item == nil {
+		return nil
+	}
+
+	f.writer.WriteByte('{')
+	first := true
+	for _, key := range f.columns {
+		value, ok := item[key]
+		if !ok {
+			continue
+		}
+		if !first {
+			f.writer.WriteByte(',')
+		}
+		first = false
+		k, _ := json.Marshal(key)
+		v, _ := json.Marshal(value)
+		f.writer.Write(k)
+		f.writer.WriteByte(':')
+		f.writer.Write(v)
+	}
+	f.writer.WriteString("}\n")
+	return nil
+}
+
+// Flush flushes buffer to file.
+func (f *JSONWriter) Flush() error {
+	return f.writer.Flush()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func TestJSONWriter_Write_Full(t *testing.T) {
+
+	entry := logcat.Entry{
+		"time":     "12-28 18:54:07.180",
+		"pid":      "930",
+		"tid":      "931",
+		"priority": "I",
+		"tag":      "auditd",
+		"message":  "  test \"Message\"",
+	}
+	expected := `{"time":"12-28 18:54:07.180","pid":"930","tid":"931","priority":"I","tag":"auditd","message":"  test \"Message\""}` + "\n"
+
+	writer := new(bytes.Buffer)
+	jsonWriter := NewJSONWriter(writer, nil)
+	jsonWriter.Write(entry)
+	jsonWriter.Flush()
+
+	if !(writer.String() == expected) {
+		t.Errorf("expected %q to eq %q", writer.String(), expected)
+	}
+}
+
+func TestJSONWriter_Write_Columns(t *testing.T) {
+
+	entry := logcat.Entry{
+		"time":    "12-28 18:54:07.180",
+		"message": "test Message",
+		"tag":     "auditd",
+	}
+	expected := `{"message":"test Message","time":"12-28 18:54:07.180"}` + "\n"
+
+	writer := new(bytes.Buffer)
+	jsonWriter := NewJSONWriter(writer, []string{"message", "pid", "time"})
+	jsonWriter.Write(entry)
+	jsonWriter.Flush()
+
+	if !(writer.String() == expected) {
+		t.Errorf("expected %q to eq %q", writer.String(), expected)
+	}
+}
+
+func TestJSONWriter_Nil(t *testing.T) {
+
+	expected := ""
+
+	writer := new(bytes.Buffer)
+	jsonWriter := NewJSONWriter(writer, nil)
+	jsonWriter.Write(nil)
+	jsonWriter.Flush()
+
+	if !(writer.String() == expected) {
+		t.Errorf("expected %q to eq %q", writer.String(), expected)
+	}
+}
@@ -3,9 +3,10 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
-	"github.com/Maki-Daisuke/go-lines"
 	"github.com/ujiro99/logcatf/logcat"
 )
 
@@ -24,6 +25,12 @@ func (l *logcat2csv) execStream(params cmdParams) int {
 }
 
 func (l *logcat2csv) execFiles(params cmdParams) int {
+	output, err := lookupFormat(params.format)
+	if err != nil {
+		fmt.Fprintf(params.error, "%s\n", err)
+		return ExitCodeError
+	}
+	shared := params.output // all files are written into this, if specified.
 	success := false
 	for _, path := range params.paths {
 		r, e := os.Open(path)
@@ -32,18 +39,31 @@ func (l *logcat2csv) execFiles(params cmdParams) int {
 			fmt.Fprintf(params.error, "File open error: %s\n", path)
 			continue
 		}
-		w, e := os.Create(path + ".csv")
-		defer w.Close()
-		if e != nil {
-			fmt.Fprintf(params.error, "File create error: %s\n", path+".csv")
-			continue
+		out := shared
+		if out == "" {
+			out = path + output.ext
+		}
+		if output.newWriter != nil {
+			w, e := os.Create(out)
+			defer w.Close()
+			if e != nil {
+				fmt.Fprintf(params.error, "File create error: %s\n", out)
+				continue
+			}
+			params.writer = w
+		}
+		if s, e := r.Stat(); e == nil {
+			params.modTime = s.ModTime()
 		}
 		params.reader = r
-		params.writer = w
+		params.output = out
+		params.source = path
 		err := l.exec(params)
 		if err != nil {
 			fmt.Fprintf(params.error, "%s: %s\n", err, path)
-			os.Remove(path + ".csv")
+			if shared == "" {
+				os.Remove(out)
+			}
 		} else {
 			success = true
 		}
@@ -56,18 +76,76 @@ func (l *logcat2csv) execFiles(params cmdParams) int {
 }
 
 func (l *logcat2csv) exec(params cmdParams) error {
-	csvWriter := NewWriter(params.writer, params.encode, params.osName)
-	parser := logcat.NewParser()
+	writer, err := newWriter(params)
+	if err != nil {
+		return err
+	}
+	if c, ok := writer.(io.Closer); ok {
+		defer c.Close()
+	}
+	err = l.scan(params, func(item logcat.Entry, line string) {
+		if err := writer.Write(item); err != nil {
+			// fmt.Printf("%s\tLine: %s\n", err, line) // for debug
+			fmt.Fprintf(params.error, "%s\tLine: %s\n", err, line)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// scan parses entries from the reader, and emits converted rows with the line they come from.
+func (l *logcat2csv) scan(params cmdParams, emit func(item logcat.Entry, line string)) error {
+	params.filters.Reset()
+	var processes *processTracker
+	if contains(params.columns, ProcessColumn) {
+		processes = newProcessTracker()
+	}
+	var elapsed *elapsedCalculator
+	if contains(params.columns, ElapsedColumn) || contains(params.columns, DeltaColumn) || contains(params.columns, PidDeltaColumn) {
+		elapsed = newElapsedCalculator()
+	}
+	var times *timeConverter
+	if params.isoTime || params.toTZ != nil {
+		years := newYearResolver(params.year, params.modTime)
+		times = newTimeConverter(years, params.fromTZ, params.toTZ, params.isoTime)
+	}
+	var epoch *time.Location
+	if params.epochToTime {
+		epoch = params.fromTZ
+		if epoch == nil {
+			epoch = time.Local
+		}
+	}
+	var crashes *crashExtractor
+	if params.extractCrashes {
+		crashes = newCrashExtractor()
+	}
+	write := func(item logcat.Entry, line string) {
+		// Convert timestamp
+		if times != nil {
+			times.Convert(item)
+		}
+		emit(item, line)
+	}
+	parser := newParser(epoch)
 	fail := 0
 	success := 0
-	for line := range lines.Lines(params.reader) {
+	done := make(chan struct{}) // stops goroutines of entries, when it returns.
+	defer close(done)
+	entries := parser.Entries(params.reader, done)
+	if params.joinMultiline {
+		entries = joinMultiline(entries, done)
+	}
+	for res := range entries {
 		if fail > MaxFailCount {
 			return errors.New("Parse error. Conversion canceled")
 		}
 
 		// Parse
-		entry, err := parser.Parse(line)
-		if err != nil {
+		entry, line := res.entry, res.line
+		if res.err != nil {
 			fail++ // Can't parse to logcat.
 			continue
 		}
@@ -77,22 +155,106 @@ func (l *logcat2csv) exec(params cmdParams) error {
 			success++
 		}
 
+		// Derive columns
+		if processes != nil {
+			processes.Track(entry)
+			entry[ProcessColumn] = processes.Name(entry["pid"])
+		}
+		if elapsed != nil {
+			elapsed.Calculate(entry)
+		}
+
+		// Filter
+		if !params.filters.Match(entry) {
+			continue
+		}
+
 		// Write
-		err = csvWriter.Write(entry)
-		if err != nil {
-			// fmt.Printf("%s\tLine: %s\n", err, line) // for debug
-			fmt.Fprintf(params.error, "%s\tLine: %s\n", err, line)
+		if crashes == nil {
+			write(entry, line)
+			continue
+		}
+		for _, row := range crashes.Extract(entry) {
+			write(row, line)
 		}
 	}
 	if success <= 0 {
 		return errors.New("Format error. Conversion canceled")
 	}
-	csvWriter.Flush()
+	if crashes != nil {
+		for _, row := range crashes.Flush() {
+			write(row, row[TraceColumn])
+		}
+	}
 	return nil
 }
 
+// execSignatures counts crashes of all inputs by signatures, and writes the frequency table.
+func (l *logcat2csv) execSignatures(params cmdParams) int {
+	signatures := newSignatureCounter(params.obfuscated)
+	success := false
+	if params.reader != nil {
+		success = l.countSignatures(params, signatures)
+	}
+	for _, path := range params.paths {
+		r, e := os.Open(path)
+		if e != nil {
+			fmt.Fprintf(params.error, "File open error: %s\n", path)
+			continue
+		}
+		p := params
+		p.reader = r
+		p.source = path
+		if s, e := r.Stat(); e == nil {
+			p.modTime = s.ModTime()
+		}
+		if l.countSignatures(p, signatures) {
+			success = true
+		}
+		r.Close() // inputs may be too many to keep open.
+	}
+	if !success {
+		return ExitCodeError
+	}
+
+	writer, err := newWriter(params)
+	if err != nil {
+		fmt.Fprintf(params.error, "%s\n", err)
+		return ExitCodeError
+	}
+	if c, ok := writer.(io.Closer); ok {
+		defer c.Close()
+	}
+	for _, row := range signatures.Rows() {
+		if err := writer.Write(row); err != nil {
+			fmt.Fprintf(params.error, "%s\tSignature: %s\n", err, row[SignatureColumn])
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		fmt.Fprintf(params.error, "%s\n", err)
+		return ExitCodeError
+	}
+	return ExitCodeOK
+}
+
+// countSignatures counts crashes of the input by signatures.
+// It returns false, if the input can't be converted.
+func (l *logcat2csv) countSignatures(params cmdParams, signatures *signatureCounter) bool {
+	err := l.scan(params, func(item logcat.Entry, line string) {
+		signatures.Add(item, params.source)
+	})
+	if err != nil {
+		fmt.Fprintf(params.error, "%s: %s\n", err, params.source)
+		return false
+	}
+	return true
+}
+
 // Exec execute converting.
 func (l *logcat2csv) Exec(params cmdParams) int {
+	if params.crashSignatures {
+		return l.execSignatures(params)
+	}
 	if params.reader != nil {
 		return l.execStream(params)
 	}
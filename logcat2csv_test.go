@@ -9,8 +9,10 @@ import (
 	"testing"
 )
 
+const header = "time,pid,tid,priority,tag,message"
+
 func TestLogcat2csv_Exec_Stdio(t *testing.T) {
-	expect := "01-01 00:00:00.000,930,931,I,tag_value,message_value\n"
+	expect := header + "\n" + "01-01 00:00:00.000,930,931,I,tag_value,message_value\n"
 	out := new(bytes.Buffer)
 	params := cmdParams{
 		reader: strings.NewReader("01-01 00:00:00.000   930   931 I tag_value  : message_value"),
@@ -26,6 +28,7 @@ func TestLogcat2csv_Exec_Stdio(t *testing.T) {
 
 func TestLogcat2csv_Exec_File(t *testing.T) {
 	expect := []string{
+		header,
 		"01-01 00:00:00.000,930,931,I,tag_value,message_value_1",
 		"01-01 00:00:01.000,930,931,I,tag_value,message_value_2",
 	}
@@ -80,10 +83,12 @@ func TestLogcat2csv_Exec_File_Raw(t *testing.T) {
 
 func TestLogcat2csv_Exec_Multiple_File(t *testing.T) {
 	expect0 := []string{
+		header,
 		"01-01 00:00:00.000,930,931,I,tag_value,message_value_1",
 		"01-01 00:00:01.000,930,931,I,tag_value,message_value_2",
 	}
 	expect1 := []string{
+		header,
 		"01-01 00:00:00.000,930,931,I,tag_value,message_value_3",
 		"01-01 00:00:01.000,930,931,I,tag_value,message_value_4",
 	}
@@ -104,13 +109,17 @@ func TestLogcat2csv_Exec_Multiple_File(t *testing.T) {
 }
 
 func checkFile(file string, expect []string) error {
+	return checkOutput(file, ".csv", expect)
+}
+
+func checkOutput(file, ext string, expect []string) error {
 	var out string
-	fp, err := os.Open(file + ".csv")
+	fp, err := os.Open(file + ext)
 	if err != nil {
 		return err
 	}
 	defer fp.Close()
-	defer os.Remove(file + ".csv")
+	defer os.Remove(file + ext)
 
 	if expect != nil {
 		scanner := bufio.NewScanner(fp)
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+// markdownEscaper escapes html in cells too, since newlines are written as `<br>`.
+var markdownEscaper = strings.NewReplacer(
+	`|`, `\|`,
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	"\r\n", "<br>",
+	"\n", "<br>",
+	"\r", "<br>",
+)
+
+// MarkdownWriter writes logcat.Entry as a GitHub Flavored Markdown table.
+type MarkdownWriter struct {
+	writer  *bufio.Writer
+	columns []string
+}
+
+// NewMarkdownWriter creates new MarkdownWriter.
+// If `columns` is empty, Columns is used.
+func NewMarkdownWriter(w io.Writer, columns []string) *MarkdownWriter {
+	if len(columns) == 0 {
+		columns = Columns
+	}
+	res := &MarkdownWriter{
+		writer:  bufio.NewWriter(w),
+		columns: columns,
+	}
+	res.writeRow(columns)
+	separator := make([]string, len(columns))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	res.writeRow(separator)
+	return res
+}
+
+// Write writes logcat.Entry as a table row.
+func (f *MarkdownWriter) Write(item logcat.Entry) error {
+	if item == nil {
+		return nil
+	}
+
+	values := make([]string, len(f.columns))
+	for i, key := range f.columns {
+		values[i] = markdownEscaper.Replace(item[key])
+	}
+	f.writeRow(values)
+	return nil
+}
+
+// Flush flushes buffer to file.
+func (f *MarkdownWriter) Flush() error {
+	return f.writer.Flush()
+}
+
+func (f *MarkdownWriter) writeRow(values []string) {
+	f.writer.WriteString("| ")
+	f.writer.WriteString(strings.Join(values, " | "))
+	f.writer.WriteString(" |\n")
+}
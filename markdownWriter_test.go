@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func TestMarkdownWriter_Write(t *testing.T) {
+
+	entry := logcat.Entry{
+		"time":    "12-28 18:54:07.180",
+		"tag":     "auditd",
+		"message": "a | b\nc",
+	}
+	expected := "| time | tag | message |\n" +
+		"| --- | --- | --- |\n" +
+		"| 12-28 18:54:07.180 | auditd | a \\| b<br>c |\n"
+
+	writer := new(bytes.Buffer)
+	markdownWriter := NewMarkdownWriter(writer, []string{"time", "tag", "message"})
+	markdownWriter.Write(entry)
+	markdownWriter.Flush()
+
+	if !(writer.String() == expected) {
+		t.Errorf("expected %q to eq %q", writer.String(), expected)
+	}
+}
+
+func TestMarkdownWriter_Write_Html(t *testing.T) {
+
+	entry := logcat.Entry{
+		"message": "java.lang.IllegalStateException: a & b\n\tat Foo.<init>(Foo.java:3)",
+	}
+	expected := "| message |\n" +
+		"| --- |\n" +
+		"| java.lang.IllegalStateException: a &amp; b<br>\tat Foo.&lt;init&gt;(Foo.java:3) |\n"
+
+	writer := new(bytes.Buffer)
+	markdownWriter := NewMarkdownWriter(writer, []string{"message"})
+	markdownWriter.Write(entry)
+	markdownWriter.Flush()
+
+	if !(writer.String() == expected) {
+		t.Errorf("expected %q to eq %q", writer.String(), expected)
+	}
+}
+
+func TestMarkdownWriter_Nil(t *testing.T) {
+
+	expected := "| time | pid | tid | priority | tag | message |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n"
+
+	writer := new(bytes.Buffer)
+	markdownWriter := NewMarkdownWriter(writer, nil)
+	markdownWriter.Write(nil)
+	markdownWriter.Flush()
+
+	if !(writer.String() == expected) {
+		t.Errorf("expected %q to eq %q", writer.String(), expected)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+// e.g. `	at com.foo.Bar.run(Bar.java:10)`, `Caused by: java.lang.Exception`, `	... 12 more`
+var continuationRegexp = regexp.MustCompile(`^\s*(at\s|Caused by:|Suppressed:|\.\.\. \d+ more)`)
+
+// joinMultiline merges consecutive entries of a burst into an entry,
+// which are written by the same pid, tid and tag.
+// When `done` is closed, it stops sending.
+func joinMultiline(in <-chan parsed, done <-chan struct{}) <-chan parsed {
+	ch := make(chan parsed)
+	go func() {
+		defer close(ch)
+		send := func(res parsed) bool {
+			select {
+			case ch <- res:
+				return true
+			case <-done:
+				return false
+			}
+		}
+
+		var pending *parsed
+		for res := range in {
+			if pending != nil && res.err == nil && isContinuation(pending.entry, res.entry) {
+				pending.entry[Message] = pending.entry[Message] + "\n" + res.entry[Message]
+				continue
+			}
+			if pending != nil {
+				if !send(*pending) {
+					return
+				}
+				pending = nil
+			}
+			if res.err != nil || res.entry.Format() == "raw" {
+				if !send(res) {
+					return
+				}
+				continue
+			}
+			pending = &parsed{entry: res.entry, line: res.line}
+		}
+		if pending != nil {
+			send(*pending)
+		}
+	}()
+	return ch
+}
+
+// isContinuation returns true, if `entry` continues from `prev`.
+// An entry continues, if it has the same timestamp, or looks like a line of a stack trace.
+// Entries without timestamps, e.g. brief format, are joined only by the latter.
+func isContinuation(prev, entry logcat.Entry) bool {
+	for _, key := range []string{"pid", "tid", "tag", "priority"} {
+		if prev[key] != entry[key] {
+			return false
+		}
+	}
+	if entry.Format() == "raw" {
+		return false
+	}
+	if entry["time"] != "" && prev["time"] == entry["time"] {
+		return true
+	}
+	return continuationRegexp.MatchString(entry[Message])
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func TestJoinMultiline(t *testing.T) {
+	input := "12-28 18:54:07.180   930   931 E AndroidRuntime: FATAL EXCEPTION: main\n" +
+		"12-28 18:54:07.180   930   931 E AndroidRuntime: Process: com.example, PID: 930\n" +
+		"12-28 18:54:07.181   930   931 E AndroidRuntime: \tat com.example.Main.run(Main.java:10)\n" +
+		"12-28 18:54:07.181   930   931 E AndroidRuntime: Caused by: java.lang.NullPointerException\n" +
+		"12-28 18:54:07.181   930   931 E AndroidRuntime: \t... 12 more\n" +
+		"12-28 18:54:07.181   930   932 E AndroidRuntime: \tat com.example.Other.run(Other.java:20)\n" +
+		"12-28 18:54:07.190   930   931 E AndroidRuntime: next message\n" +
+		"not a logcat line\n" +
+		"12-28 18:54:07.190   930   931 E AndroidRuntime: after raw line\n"
+	expect := []logcat.Entry{
+		{"time": "12-28 18:54:07.180", "pid": "930", "tid": "931", "priority": "E", "tag": "AndroidRuntime",
+			"message": "FATAL EXCEPTION: main\nProcess: com.example, PID: 930\n\tat com.example.Main.run(Main.java:10)\nCaused by: java.lang.NullPointerException\n\t... 12 more"},
+		{"time": "12-28 18:54:07.181", "pid": "930", "tid": "932", "priority": "E", "tag": "AndroidRuntime",
+			"message": "\tat com.example.Other.run(Other.java:20)"},
+		{"time": "12-28 18:54:07.190", "pid": "930", "tid": "931", "priority": "E", "tag": "AndroidRuntime",
+			"message": "next message"},
+		{"message": "not a logcat line"},
+		{"time": "12-28 18:54:07.190", "pid": "930", "tid": "931", "priority": "E", "tag": "AndroidRuntime",
+			"message": "after raw line"},
+	}
+
+	var entries []logcat.Entry
+	for res := range joinMultiline(newParser(nil).Entries(strings.NewReader(input), nil), nil) {
+		entries = append(entries, res.entry)
+	}
+	if !reflect.DeepEqual(entries, expect) {
+		t.Errorf("\n  result: %q\n  expect: %q", entries, expect)
+	}
+}
+
+func TestJoinMultiline_Brief(t *testing.T) {
+	input := "I/MyApp  (  930): first\n" +
+		"I/MyApp  (  930): second\n" +
+		"E/MyApp  (  930): java.lang.IllegalStateException\n" +
+		"E/MyApp  (  930): \tat com.example.Main.run(Main.java:10)\n" +
+		"I/MyApp  (  930): third\n"
+	expect := []string{
+		"first",
+		"second",
+		"java.lang.IllegalStateException\n\tat com.example.Main.run(Main.java:10)",
+		"third",
+	}
+
+	var messages []string
+	for res := range joinMultiline(newParser(nil).Entries(strings.NewReader(input), nil), nil) {
+		messages = append(messages, res.entry[Message])
+	}
+	if !reflect.DeepEqual(messages, expect) {
+		t.Errorf("\n  result: %q\n  expect: %q", messages, expect)
+	}
+}
+
+func TestJoinMultiline_Done(t *testing.T) {
+	input := strings.Repeat("12-28 18:54:07.180   930   931 I tag: message\n", 100)
+	before := runtime.NumGoroutine()
+
+	done := make(chan struct{})
+	<-joinMultiline(newParser(nil).Entries(strings.NewReader(input), done), done)
+	close(done)
+
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("expected %d goroutines to eq %d", n, before)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ujiro99/logcatf/logcat"
+	"github.com/xitongsys/parquet-go/parquet"
+	pq "github.com/xitongsys/parquet-go/writer"
+)
+
+// parquetRow represents a row of parquet file.
+// Fields which are missing in a logcat.Entry are stored as null.
+type parquetRow struct {
+	Time     *int64  `parquet:"name=time, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=false, logicaltype.unit=MILLIS, repetitiontype=OPTIONAL"`
+	Pid      *int32  `parquet:"name=pid, type=INT32, repetitiontype=OPTIONAL"`
+	Tid      *int32  `parquet:"name=tid, type=INT32, repetitiontype=OPTIONAL"`
+	Priority *string `parquet:"name=priority, type=BYTE_ARRAY, convertedtype=ENUM, repetitiontype=OPTIONAL"`
+	Tag      *string `parquet:"name=tag, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	Message  *string `parquet:"name=message, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+}
+
+// ParquetWriter writes logcat.Entry to a parquet file, with typed columns.
+// The schema is fixed, so columns option is not supported.
+type ParquetWriter struct {
+	writer *pq.ParquetWriter
+	years  *yearResolver
+}
+
+// NewParquetWriter creates new ParquetWriter.
+// `years` resolves years of timestamps, because logcat doesn't output it.
+func NewParquetWriter(w io.Writer, years *yearResolver) (*ParquetWriter, error) {
+	pw, err := pq.NewParquetWriterFromWriter(w, new(parquetRow), 4)
+	if err != nil {
+		return nil, err
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	return &ParquetWriter{
+		writer: pw,
+		years:  years,
+	}, nil
+}
+
+// Write writes logcat.Entry as a row.
+func (f *ParquetWriter) Write(item logcat.Entry) error {
+	if item == nil {
+		return nil
+	}
+	return f.writer.Write(f.row(item))
+}
+
+// Flush writes the footer, and flushes it to file.
+func (f *ParquetWriter) Flush() error {
+	return f.writer.WriteStop()
+}
+
+func (f *ParquetWriter) row(item logcat.Entry) parquetRow {
+	return parquetRow{
+		Time:     f.timestamp(item["time"]),
+		Pid:      int32Value(item["pid"]),
+		Tid:      int32Value(item["tid"]),
+		Priority: stringValue(item["priority"]),
+		Tag:      stringValue(item["tag"]),
+		Message:  stringValue(item[Message]),
+	}
+}
+
+// timestamp returns milliseconds of `str` since the epoch, without timezone adjustment.
+func (f *ParquetWriter) timestamp(str string) *int64 {
+	t, err := f.years.Resolve(str)
+	if err != nil {
+		// epoch, monotonic or ISO 8601 time.
+		if t, err = parseTime(str, 0); err != nil {
+			return nil
+		}
+	}
+	t = withYear(t, t.Year()) // wall clock of ISO 8601 timestamps with timezone.
+	ms := t.UnixNano() / int64(time.Millisecond)
+	return &ms
+}
+
+func int32Value(str string) *int32 {
+	i, err := strconv.ParseInt(str, 10, 32)
+	if err != nil {
+		return nil
+	}
+	v := int32(i)
+	return &v
+}
+
+func stringValue(str string) *string {
+	if str == "" {
+		return nil
+	}
+	return &str
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func TestParquetWriter_row(t *testing.T) {
+
+	entry := logcat.Entry{
+		"time":     "12-28 18:54:07.180",
+		"pid":      "930",
+		"priority": "I",
+		"tag":      "auditd",
+		"message":  "test Message",
+	}
+
+	f := &ParquetWriter{years: newYearResolver(2017, time.Time{})}
+	row := f.row(entry)
+
+	if row.Time == nil || *row.Time != 1514487247180 {
+		t.Errorf("unexpected time: %v", row.Time)
+	}
+	if row.Pid == nil || *row.Pid != 930 {
+		t.Errorf("unexpected pid: %v", row.Pid)
+	}
+	if row.Tid != nil {
+		t.Errorf("expected tid %v to be nil", *row.Tid)
+	}
+	if row.Priority == nil || *row.Priority != "I" {
+		t.Errorf("unexpected priority: %v", row.Priority)
+	}
+	if row.Message == nil || *row.Message != "test Message" {
+		t.Errorf("unexpected message: %v", row.Message)
+	}
+}
+
+func TestParquetWriter_row_Raw(t *testing.T) {
+
+	entry := logcat.Entry{
+		"message": "--------- beginning of main",
+	}
+
+	f := &ParquetWriter{years: newYearResolver(2017, time.Time{})}
+	row := f.row(entry)
+
+	if row.Time != nil || row.Pid != nil || row.Tag != nil {
+		t.Errorf("expected fields to be nil: %+v", row)
+	}
+	if row.Message == nil || *row.Message != entry["message"] {
+		t.Errorf("unexpected message: %v", row.Message)
+	}
+}
+
+func TestParquetWriter_row_Zone(t *testing.T) {
+	for _, str := range []string{"2017-12-28T18:54:07.180", "2017-12-28T18:54:07.180+09:00"} {
+		f := &ParquetWriter{years: newYearResolver(2000, time.Time{})}
+		row := f.row(logcat.Entry{"time": str})
+
+		if row.Time == nil || *row.Time != 1514487247180 {
+			t.Errorf("unexpected time of %s: %v", str, row.Time)
+		}
+	}
+}
+
+func TestParquetWriter_row_Rollover(t *testing.T) {
+	f := &ParquetWriter{years: newYearResolver(0, time.Date(2018, 1, 1, 1, 0, 0, 0, time.UTC))}
+	first := f.row(logcat.Entry{"time": "12-31 23:59:59.000"})
+	second := f.row(logcat.Entry{"time": "01-01 00:00:01.000"})
+
+	if first.Time == nil || *first.Time != 1514764799000 {
+		t.Errorf("unexpected time: %v", first.Time)
+	}
+	if second.Time == nil || *second.Time != 1514764801000 {
+		t.Errorf("unexpected time: %v", second.Time)
+	}
+}
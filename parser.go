@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+// ZoneKey represents key of timezone in a logcat.Entry, which is output with `-v zone` modifier.
+const ZoneKey = "zone"
+
+// MinEpoch represents the minimum seconds which is regarded as epoch time, not monotonic time.
+const MinEpoch = 1000000000
+
+// maxLineSize represents the maximum length of a line.
+const maxLineSize = 1024 * 1024
+
+// placeholderTime is put instead of seconds, to be parsed by logcat.Parser.
+const placeholderTime = "01-01 00:00:00.000"
+
+var (
+	// e.g. `12-28 18:54:07.180 +0900   930   931 I tag: message`
+	zoneRegexp = regexp.MustCompile(`^(\d\d-\d\d \d\d:\d\d:\d\d\.\d+) ([+-]\d{4}) (.*)$`)
+	// e.g. `1514454847.180   930   931 I tag: message`, with `-v epoch` or `-v monotonic`
+	secondsRegexp = regexp.MustCompile(`^\s*(\d+)\.(\d{3,9})(\s.*)$`)
+	// e.g. `[ 12-28 18:54:07.180   930:  931 I/tag ]`, header of `-v long` format
+	longRegexp = regexp.MustCompile(`^\[ (\d\d-\d\d \d\d:\d\d:\d\d\.\d+|\d+\.\d+)(?: ([+-]\d{4}))?\s+(\d+):\s*(\d+) ([VDIWEFA])/(.*?)\s*\]$`)
+)
+
+// dividerPrefix represents prefix of lines which divide buffers, like `--------- beginning of main`.
+const dividerPrefix = "--------- "
+
+// parsed represents a parsed entry, and the line which it is parsed from.
+type parsed struct {
+	entry logcat.Entry
+	line  string
+	err   error
+}
+
+// parser wraps logcat.Parser, to support formats and modifiers which it doesn't support.
+type parser struct {
+	parser logcat.Parser
+	epoch  *time.Location
+}
+
+// newParser creates parser.
+// If `epoch` isn't nil, epoch time is converted to wall-clock time in the location.
+func newParser(epoch *time.Location) *parser {
+	return &parser{parser: logcat.NewParser(), epoch: epoch}
+}
+
+// Parse parses a line of logcat.
+func (p *parser) Parse(line string) (logcat.Entry, error) {
+	if m := zoneRegexp.FindStringSubmatch(line); m != nil {
+		entry, err := p.parser.Parse(m[1] + " " + m[3])
+		if err == nil && entry != nil && entry["time"] != "" {
+			entry[ZoneKey] = m[2]
+		}
+		return entry, err
+	}
+	if m := secondsRegexp.FindStringSubmatch(line); m != nil {
+		entry, err := p.parser.Parse(placeholderTime + m[3])
+		if err == nil && entry != nil && entry["time"] == placeholderTime {
+			p.setSeconds(entry, m[1], m[2])
+			return entry, nil
+		}
+	}
+	return p.parser.Parse(line)
+}
+
+// Entries parses lines read from `r`, and sends parsed entries.
+// Multi-line entries of `-v long` format are joined into an entry.
+// When `done` is closed, it stops reading `r`.
+func (p *parser) Entries(r io.Reader, done <-chan struct{}) <-chan parsed {
+	ch := make(chan parsed)
+	go func() {
+		defer close(ch)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, bufio.MaxScanTokenSize), maxLineSize)
+		send := func(res parsed) bool {
+			select {
+			case ch <- res:
+				return true
+			case <-done:
+				return false
+			}
+		}
+
+		var long *parsed // entry of long format, which is being read.
+		var body []string
+		flush := func() bool {
+			if long == nil {
+				return true
+			}
+			// the last empty line is a separator of entries.
+			for len(body) > 0 && body[len(body)-1] == "" {
+				body = body[:len(body)-1]
+			}
+			long.entry[Message] = strings.Join(body, "\n")
+			res := *long
+			long, body = nil, nil
+			return send(res)
+		}
+
+		for scanner.Scan() {
+			line := scanner.Text()
+			if entry := p.parseLongHeader(line); entry != nil {
+				if !flush() {
+					return
+				}
+				long = &parsed{entry: entry, line: line}
+				continue
+			}
+			if long != nil && !strings.HasPrefix(line, dividerPrefix) {
+				body = append(body, line)
+				continue
+			}
+			if !flush() {
+				return
+			}
+			entry, err := p.Parse(line)
+			if !send(parsed{entry: entry, line: line, err: err}) {
+				return
+			}
+		}
+		if !flush() {
+			return
+		}
+		if err := scanner.Err(); err != nil {
+			send(parsed{err: err})
+		}
+	}()
+	return ch
+}
+
+// parseLongHeader parses a header of `-v long` format.
+// It returns nil, if `line` isn't a header.
+func (p *parser) parseLongHeader(line string) logcat.Entry {
+	m := longRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	entry := logcat.Entry{
+		"time":     m[1],
+		"pid":      m[3],
+		"tid":      m[4],
+		"priority": m[5],
+		"tag":      m[6],
+	}
+	if m[2] != "" {
+		entry[ZoneKey] = m[2]
+	}
+	if i := strings.Index(m[1], "."); !strings.Contains(m[1], " ") {
+		p.setSeconds(entry, m[1][:i], m[1][i+1:])
+	}
+	return entry
+}
+
+// setSeconds sets `time` field of epoch or monotonic time.
+func (p *parser) setSeconds(entry logcat.Entry, sec, frac string) {
+	entry["time"] = sec + "." + frac
+	s, err := strconv.ParseInt(sec, 10, 64)
+	if p.epoch == nil || err != nil || s < MinEpoch {
+		return
+	}
+	ns, _ := strconv.ParseInt((frac + "00000000")[:9], 10, 64)
+	entry["time"] = time.Unix(s, ns).In(p.epoch).Format(isoZoneTimeLayout) // keep the year of epoch.
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func TestParser_Parse(t *testing.T) {
+	tests := []struct {
+		line   string
+		expect logcat.Entry
+	}{
+		{"12-28 18:54:07.180   930   931 I auditd  : test Message",
+			logcat.Entry{"time": "12-28 18:54:07.180", "pid": "930", "tid": "931", "priority": "I", "tag": "auditd", "message": "test Message"}},
+		{"12-28 18:54:07.180 +0900   930   931 I auditd  : test Message",
+			logcat.Entry{"time": "12-28 18:54:07.180", "zone": "+0900", "pid": "930", "tid": "931", "priority": "I", "tag": "auditd", "message": "test Message"}},
+	}
+
+	p := newParser(nil)
+	for _, test := range tests {
+		entry, err := p.Parse(test.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(entry, test.expect) {
+			t.Errorf("expected %v to eq %v", entry, test.expect)
+		}
+	}
+}
+
+func TestParser_Parse_Seconds(t *testing.T) {
+	jst, _ := loadLocation("+0900")
+	tests := []struct {
+		epoch  *time.Location
+		line   string
+		expect logcat.Entry
+	}{
+		{nil, "1514454847.180   930   931 I auditd  : test Message",
+			logcat.Entry{"time": "1514454847.180", "pid": "930", "tid": "931", "priority": "I", "tag": "auditd", "message": "test Message"}},
+		{nil, "    12.345678   930   931 I auditd  : test Message",
+			logcat.Entry{"time": "12.345678", "pid": "930", "tid": "931", "priority": "I", "tag": "auditd", "message": "test Message"}},
+		{jst, "1514454847.180   930   931 I auditd  : test Message",
+			logcat.Entry{"time": "2017-12-28T18:54:07.180+09:00", "pid": "930", "tid": "931", "priority": "I", "tag": "auditd", "message": "test Message"}},
+		{jst, "    12.345678   930   931 I auditd  : test Message",
+			logcat.Entry{"time": "12.345678", "pid": "930", "tid": "931", "priority": "I", "tag": "auditd", "message": "test Message"}},
+		{jst, "1514454847.180 I/auditd(  930): test Message",
+			logcat.Entry{"time": "2017-12-28T18:54:07.180+09:00", "pid": "930", "priority": "I", "tag": "auditd", "message": "test Message"}},
+		{jst, "3.14 is not a log",
+			logcat.Entry{"message": "3.14 is not a log"}},
+	}
+
+	for _, test := range tests {
+		p := newParser(test.epoch)
+		entry, err := p.Parse(test.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(entry, test.expect) {
+			t.Errorf("expected %v to eq %v", entry, test.expect)
+		}
+	}
+}
+
+func TestParser_Entries_Long(t *testing.T) {
+	input := "--------- beginning of main\n" +
+		"[ 12-28 18:54:07.180   930:  931 I/auditd ]\n" +
+		"test Message\n" +
+		"\n" +
+		"[ 12-28 18:54:08.180 +0900   930:  932 E/AndroidRuntime ]\n" +
+		"FATAL EXCEPTION: main\n" +
+		"\tat com.example.Main.run(Main.java:10)\n" +
+		"\n"
+	expect := []logcat.Entry{
+		{"message": "--------- beginning of main"},
+		{"time": "12-28 18:54:07.180", "pid": "930", "tid": "931", "priority": "I", "tag": "auditd", "message": "test Message"},
+		{"time": "12-28 18:54:08.180", "zone": "+0900", "pid": "930", "tid": "932", "priority": "E", "tag": "AndroidRuntime",
+			"message": "FATAL EXCEPTION: main\n\tat com.example.Main.run(Main.java:10)"},
+	}
+
+	p := newParser(nil)
+	var entries []logcat.Entry
+	for res := range p.Entries(strings.NewReader(input), nil) {
+		if res.err != nil {
+			t.Fatal(res.err)
+		}
+		entries = append(entries, res.entry)
+	}
+	if !reflect.DeepEqual(entries, expect) {
+		t.Errorf("expected %v to eq %v", entries, expect)
+	}
+}
+
+func TestParser_Entries_LongEpoch(t *testing.T) {
+	jst, _ := loadLocation("+0900")
+	expect := logcat.Entry{"time": "2017-12-28T18:54:07.180+09:00", "pid": "930", "tid": "931", "priority": "I", "tag": "auditd", "message": "test Message"}
+
+	p := newParser(jst)
+	res := <-p.Entries(strings.NewReader("[ 1514454847.180   930:  931 I/auditd ]\ntest Message\n\n"), nil)
+	if !reflect.DeepEqual(res.entry, expect) {
+		t.Errorf("expected %v to eq %v", res.entry, expect)
+	}
+}
+
+// endlessReader repeats a logcat line forever.
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	line := "12-28 18:54:07.180   930   931 I tag: message\n"
+	n := 0
+	for n+len(line) <= len(p) {
+		n += copy(p[n:], line)
+	}
+	return n, nil
+}
+
+func TestParser_Entries_Done(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	done := make(chan struct{})
+	<-newParser(nil).Entries(endlessReader{}, done)
+	close(done)
+
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("expected %d goroutines to eq %d", n, before)
+	}
+}
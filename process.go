@@ -0,0 +1,78 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+var (
+	// e.g. `Start proc 1234:com.example.app/u0a123 for activity {...}`
+	startProcRegexp = regexp.MustCompile(`^Start proc (\d+):([^/\s]+)`)
+	// e.g. `Start proc com.example.app for activity {...}: pid=1234 uid=10123 gids={...}`
+	startProcOldRegexp = regexp.MustCompile(`^Start proc ([^\s]+) for .*: pid=(\d+)`)
+	// e.g. `Process com.example.app (pid 1234) has died`
+	diedRegexp = regexp.MustCompile(`^Process ([^\s]+) \(pid (\d+)\) has died`)
+)
+
+// ProcessColumn represents column name of process names, which is derived from the log.
+const ProcessColumn = "process"
+
+// processTracker tracks process names of pids, from ActivityManager events in the log.
+type processTracker struct {
+	names map[string]string
+}
+
+func newProcessTracker() *processTracker {
+	return &processTracker{names: map[string]string{}}
+}
+
+// Reset forgets all processes.
+func (p *processTracker) Reset() {
+	p.names = map[string]string{}
+}
+
+// Track updates process names, if `item` is an event of process lifecycle.
+func (p *processTracker) Track(item logcat.Entry) {
+	message := item[Message]
+	switch item["tag"] {
+	case "ActivityManager":
+		if m := startProcRegexp.FindStringSubmatch(message); m != nil {
+			p.names[m[1]] = m[2]
+		} else if m := startProcOldRegexp.FindStringSubmatch(message); m != nil {
+			p.names[m[2]] = m[1]
+		} else if m := diedRegexp.FindStringSubmatch(message); m != nil {
+			delete(p.names, m[2])
+		}
+	case "am_proc_start":
+		// [User, PID, UID, Process Name, Type, Component], User is omitted in old versions.
+		fields := eventFields(message)
+		if len(fields) >= 6 {
+			p.names[fields[1]] = fields[3]
+		} else if len(fields) == 5 {
+			p.names[fields[0]] = fields[2]
+		}
+	case "am_proc_died":
+		// [User, PID, Process Name, ...], User is omitted in old versions.
+		fields := eventFields(message)
+		if len(fields) >= 3 && isInt(fields[1]) {
+			delete(p.names, fields[1])
+		} else if len(fields) >= 2 {
+			delete(p.names, fields[0])
+		}
+	}
+}
+
+// eventFields splits the message of events buffer, like `[0,1234,com.example.app]`.
+func eventFields(message string) []string {
+	if !strings.HasPrefix(message, "[") || !strings.HasSuffix(message, "]") {
+		return nil
+	}
+	return strings.Split(message[1:len(message)-1], ",")
+}
+
+// Name returns process name of the `pid`, or empty string if it is unknown.
+func (p *processTracker) Name(pid string) string {
+	return p.names[pid]
+}
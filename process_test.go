@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func TestProcessTracker_Track(t *testing.T) {
+	tests := []struct {
+		entry logcat.Entry
+		pid   string
+		name  string
+	}{
+		{logcat.Entry{"tag": "ActivityManager", "message": "Start proc 2000:com.example.app/u0a123 for activity {com.example.app/.Main}"}, "2000", "com.example.app"},
+		{logcat.Entry{"tag": "ActivityManager", "message": "Process com.example.app (pid 2000) has died: fore TOP"}, "2000", ""},
+		{logcat.Entry{"tag": "ActivityManager", "message": "Start proc com.old.app for broadcast com.old.app/.Receiver: pid=2100 uid=10050 gids={50050}"}, "2100", "com.old.app"},
+		{logcat.Entry{"tag": "ActivityManager", "message": "Process com.old.app (pid 2100) has died"}, "2100", ""},
+		{logcat.Entry{"tag": "am_proc_start", "message": "[0,3000,10123,com.example.app,activity,com.example.app/.Main]"}, "3000", "com.example.app"},
+		{logcat.Entry{"tag": "am_proc_died", "message": "[0,3000,com.example.app,900,2]"}, "3000", ""},
+		{logcat.Entry{"tag": "am_proc_start", "message": "[3100,10123,com.example.app,activity,com.example.app/.Main]"}, "3100", "com.example.app"},
+		{logcat.Entry{"tag": "am_proc_died", "message": "[3100,com.example.app]"}, "3100", ""},
+		{logcat.Entry{"tag": "MyApp", "message": "Start proc 4000:com.fake/u0a1 for activity"}, "4000", ""},
+	}
+
+	processes := newProcessTracker()
+	for _, test := range tests {
+		processes.Track(test.entry)
+		if name := processes.Name(test.pid); name != test.name {
+			t.Errorf("%v: expected %q to eq %q", test.entry, name, test.name)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+// Columns of crash signatures.
+const (
+	SignatureColumn = "signature"
+	CountColumn     = "count"
+	FilesColumn     = "files"
+)
+
+// SignatureColumns represents columns of the frequency table of crash signatures.
+var SignatureColumns = []string{SignatureColumn, CountColumn, FilesColumn, ExceptionColumn, FrameColumn, TraceColumn}
+
+var (
+	// e.g. `(Main.java:10)`, `(Unknown Source:4)`
+	lineNumberRegexp = regexp.MustCompile(`:\d+\)$`)
+	// e.g. `-$$Lambda$MainActivity$Xyz123.run`
+	syntheticLambdaRegexp = regexp.MustCompile(`\$\$Lambda\$[\w$]*`)
+	// e.g. `lambda$onCreate$0`
+	lambdaRegexp = regexp.MustCompile(`lambda\$(\w+?)\$\d+`)
+	// e.g. `MainActivity$1`
+	anonymousRegexp = regexp.MustCompile(`\$\d+`)
+	// e.g. `a`, `bc`, `a0` in `com.example.a.bc.a0(SourceFile)`
+	obfuscatedRegexp = regexp.MustCompile(`\b[a-z][a-z0-9]?\b`)
+	digitsRegexp     = regexp.MustCompile(`\d+`)
+)
+
+// signature represents a distinct crash, and its occurrences.
+type signature struct {
+	row   logcat.Entry
+	count int
+	files map[string]bool
+}
+
+// signatureCounter counts crashes by normalized stack traces.
+type signatureCounter struct {
+	obfuscated bool
+	signatures map[string]*signature
+	order      []string
+}
+
+// newSignatureCounter returns a counter. If `obfuscated` is true, short names of obfuscated classes and methods are ignored.
+func newSignatureCounter(obfuscated bool) *signatureCounter {
+	return &signatureCounter{obfuscated: obfuscated, signatures: map[string]*signature{}}
+}
+
+// Add counts a crash `row` extracted from `file`.
+func (c *signatureCounter) Add(row logcat.Entry, file string) {
+	trace := c.normalize(row)
+	sum := sha1.Sum([]byte(trace))
+	key := hex.EncodeToString(sum[:])[:12]
+	s, ok := c.signatures[key]
+	if !ok {
+		s = &signature{
+			row: logcat.Entry{
+				SignatureColumn: key,
+				ExceptionColumn: row[ExceptionColumn],
+				FrameColumn:     row[FrameColumn],
+				TraceColumn:     trace,
+			},
+			files: map[string]bool{},
+		}
+		c.signatures[key] = s
+		c.order = append(c.order, key)
+	}
+	s.count++
+	s.files[file] = true
+}
+
+// Rows returns the frequency table, in descending order of count.
+func (c *signatureCounter) Rows() []logcat.Entry {
+	list := make([]*signature, len(c.order))
+	for i, key := range c.order {
+		list[i] = c.signatures[key]
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].count > list[j].count })
+
+	rows := make([]logcat.Entry, len(list))
+	for i, s := range list {
+		s.row[CountColumn] = strconv.Itoa(s.count)
+		s.row[FilesColumn] = strconv.Itoa(len(s.files))
+		rows[i] = s.row
+	}
+	return rows
+}
+
+// normalize returns a stable text of the crash, which doesn't depend on builds and messages.
+func (c *signatureCounter) normalize(row logcat.Entry) string {
+	var lines []string
+	for _, line := range strings.Split(row[TraceColumn], "\n") {
+		line = strings.TrimSpace(line)
+		if m := frameRegexp.FindStringSubmatch(line); m != nil {
+			lines = append(lines, "at "+c.normalizeFrame(m[1]))
+		} else if strings.HasPrefix(line, "Caused by: ") {
+			lines = append(lines, "Caused by: "+exceptionName(line[len("Caused by: "):]))
+		}
+	}
+	if len(lines) == 0 {
+		// native crashes, ANRs and am_crash events, which have no java frames.
+		text := row[ProcessColumn] + "\n" + row[ExceptionColumn] + "\n" + row[FrameColumn]
+		return strings.TrimSpace(digitsRegexp.ReplaceAllString(text, "N"))
+	}
+	return exceptionName(row[ExceptionColumn]) + "\n" + strings.Join(lines, "\n")
+}
+
+// normalizeFrame strips line numbers, lambdas and numbers of anonymous classes from a frame.
+func (c *signatureCounter) normalizeFrame(frame string) string {
+	frame = lineNumberRegexp.ReplaceAllString(frame, ")")
+	frame = syntheticLambdaRegexp.ReplaceAllString(frame, "$$$$Lambda")
+	frame = lambdaRegexp.ReplaceAllString(frame, "lambda$$$1")
+	frame = anonymousRegexp.ReplaceAllString(frame, "$$")
+	if c.obfuscated {
+		i := strings.Index(frame, "(")
+		if i < 0 {
+			i = len(frame)
+		}
+		frame = obfuscatedRegexp.ReplaceAllString(frame[:i], "?") + frame[i:]
+	}
+	return frame
+}
+
+// exceptionName returns class name of an exception, without its message.
+func exceptionName(line string) string {
+	if m := exceptionRegexp.FindStringSubmatch(line); m != nil {
+		return m[1]
+	}
+	return line
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func TestSignatureCounter_normalizeFrame(t *testing.T) {
+	tests := []struct {
+		obfuscated    bool
+		frame, expect string
+	}{
+		{false, "com.example.app.Main.run(Main.java:10)", "com.example.app.Main.run(Main.java)"},
+		{false, "com.example.app.Main.lambda$onCreate$0(Main.java:10)", "com.example.app.Main.lambda$onCreate(Main.java)"},
+		{false, "com.example.app.-$$Lambda$Main$Xyz123.run(Unknown Source:2)", "com.example.app.-$$Lambda.run(Unknown Source)"},
+		{false, "com.example.app.Main$1.onClick(Main.java:20)", "com.example.app.Main$.onClick(Main.java)"},
+		{false, "com.example.a.bc.a(SourceFile:1)", "com.example.a.bc.a(SourceFile)"},
+		{true, "com.example.a.bc.a(SourceFile:1)", "com.example.?.?.?(SourceFile)"},
+	}
+
+	for _, test := range tests {
+		c := newSignatureCounter(test.obfuscated)
+		result := c.normalizeFrame(test.frame)
+		if result != test.expect {
+			t.Errorf("expected %q to eq %q", result, test.expect)
+		}
+	}
+}
+
+func TestSignatureCounter_Rows(t *testing.T) {
+	crash := func(line, message string) logcat.Entry {
+		return logcat.Entry{
+			ExceptionColumn: "java.lang.NullPointerException",
+			FrameColumn:     "com.example.app.Main.run(Main.java:" + line + ")",
+			TraceColumn: "FATAL EXCEPTION: main\njava.lang.NullPointerException: " + message + "\n" +
+				"\tat com.example.app.Main.run(Main.java:" + line + ")\n" +
+				"Caused by: java.lang.IllegalStateException: " + message,
+		}
+	}
+	anr := logcat.Entry{ProcessColumn: "com.example.app", ExceptionColumn: "ANR: Input dispatching timed out"}
+
+	c := newSignatureCounter(false)
+	c.Add(anr, "a.txt")
+	c.Add(crash("10", "first"), "a.txt")
+	c.Add(crash("12", "second"), "b.txt")
+	c.Add(crash("10", "third"), "b.txt")
+	rows := c.Rows()
+
+	expect := []logcat.Entry{
+		{SignatureColumn: rows[0][SignatureColumn], CountColumn: "3", FilesColumn: "2",
+			ExceptionColumn: "java.lang.NullPointerException", FrameColumn: "com.example.app.Main.run(Main.java:10)",
+			TraceColumn: "java.lang.NullPointerException\nat com.example.app.Main.run(Main.java)\nCaused by: java.lang.IllegalStateException"},
+		{SignatureColumn: rows[1][SignatureColumn], CountColumn: "1", FilesColumn: "1",
+			ExceptionColumn: "ANR: Input dispatching timed out", FrameColumn: "",
+			TraceColumn: "com.example.app\nANR: Input dispatching timed out"},
+	}
+	if !reflect.DeepEqual(rows, expect) {
+		t.Errorf("\n  result: %q\n  expect: %q", rows, expect)
+	}
+	if len(rows[0][SignatureColumn]) != 12 || rows[0][SignatureColumn] == rows[1][SignatureColumn] {
+		t.Errorf("expected %q and %q to be distinct signatures", rows[0][SignatureColumn], rows[1][SignatureColumn])
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3" // sqlite3 driver
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+// SqliteTable represents table name of logcat entries.
+const SqliteTable = "logcat"
+
+// sqliteIndexes represents columns which are indexed, if exists.
+var sqliteIndexes = []string{"time", "tag", "pid", "priority"}
+
+// SqliteWriter writes logcat.Entry to a SQLite database.
+// All entries written by a SqliteWriter are committed at once in Flush.
+type SqliteWriter struct {
+	db      *sql.DB
+	tx      *sql.Tx
+	stmt    *sql.Stmt
+	source  string
+	columns []string
+}
+
+// NewSqliteWriter opens the database of `path`, and creates table if not exists.
+// `source` is stored to every rows, to distinguish input files.
+// If `columns` is empty, Columns is used.
+func NewSqliteWriter(path, source string, columns []string) (*SqliteWriter, error) {
+	if len(columns) == 0 {
+		columns = Columns
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	res := &SqliteWriter{
+		db:      db,
+		source:  source,
+		columns: columns,
+	}
+	if err := res.init(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return res, nil
+}
+
+func (f *SqliteWriter) init() error {
+	defs := []string{"id INTEGER PRIMARY KEY AUTOINCREMENT", "source TEXT"}
+	names := []string{"source"}
+	for _, col := range f.columns {
+		typ := "TEXT"
+		if isNumericColumn(col) {
+			typ = "INTEGER"
+		}
+		defs = append(defs, strconv.Quote(col)+" "+typ)
+		names = append(names, strconv.Quote(col))
+	}
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", SqliteTable, strings.Join(defs, ", "))
+	if _, err := f.db.Exec(query); err != nil {
+		return err
+	}
+	for _, col := range sqliteIndexes {
+		if !contains(f.columns, col) {
+			continue
+		}
+		query := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_%s ON %s (%s)", SqliteTable, col, SqliteTable, strconv.Quote(col))
+		if _, err := f.db.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	tx, err := f.db.Begin()
+	if err != nil {
+		return err
+	}
+	holders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+	query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", SqliteTable, strings.Join(names, ", "), holders)
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	f.tx = tx
+	f.stmt = stmt
+	return nil
+}
+
+// Write inserts logcat.Entry as a row.
+func (f *SqliteWriter) Write(item logcat.Entry) error {
+	if item == nil {
+		return nil
+	}
+
+	values := make([]interface{}, len(f.columns)+1)
+	values[0] = f.source
+	for i, key := range f.columns {
+		value, ok := item[key]
+		switch {
+		case !ok || value == "":
+			values[i+1] = nil
+		case isNumericColumn(key) && isInt(value):
+			values[i+1], _ = strconv.Atoi(value)
+		default:
+			values[i+1] = value
+		}
+	}
+	_, err := f.stmt.Exec(values...)
+	return err
+}
+
+// Flush commits all written entries.
+func (f *SqliteWriter) Flush() error {
+	return f.tx.Commit()
+}
+
+// Close closes the database. Uncommitted entries are discarded.
+func (f *SqliteWriter) Close() error {
+	f.tx.Rollback()
+	return f.db.Close()
+}
+
+func contains(list []string, str string) bool {
+	for _, s := range list {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func querySqlite(t *testing.T, path, query string) [][]interface{} {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	cols, _ := rows.Columns()
+	var res [][]interface{}
+	for rows.Next() {
+		row := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, row)
+	}
+	return res
+}
+
+func TestSqliteWriter_Write(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "logcat2csv")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logcat.db")
+
+	for _, source := range []string{"a.txt", "b.txt"} {
+		sqliteWriter, err := NewSqliteWriter(path, source, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqliteWriter.Write(logcat.Entry{
+			"time":     "12-28 18:54:07.180",
+			"pid":      "930",
+			"priority": "I",
+			"tag":      "auditd",
+			"message":  "test Message",
+		})
+		if err := sqliteWriter.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		sqliteWriter.Close()
+	}
+
+	expected := [][]interface{}{
+		{"a.txt", "12-28 18:54:07.180", int64(930), nil, "I", "auditd", "test Message"},
+		{"b.txt", "12-28 18:54:07.180", int64(930), nil, "I", "auditd", "test Message"},
+	}
+	rows := querySqlite(t, path, "SELECT source, time, pid, tid, priority, tag, message FROM logcat ORDER BY id")
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %v to eq %v", rows, expected)
+	}
+}
+
+func TestSqliteWriter_Close_Without_Flush(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "logcat2csv")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logcat.db")
+
+	sqliteWriter, err := NewSqliteWriter(path, "a.txt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqliteWriter.Write(logcat.Entry{"message": "test Message"})
+	sqliteWriter.Close()
+
+	rows := querySqlite(t, path, "SELECT * FROM logcat")
+	if len(rows) != 0 {
+		t.Errorf("expected %v to be empty", rows)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DefaultTop represents count of tags and pids which are printed in stats.
+const DefaultTop = 10
+
+// histogramBounds represents upper bounds of lines-per-second histogram buckets.
+var histogramBounds = []int{1, 10, 100, 1000, 10000}
+
+// count represents a counted value.
+type count struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// bucket represents a bucket of lines-per-second histogram.
+type bucket struct {
+	Range   string `json:"range"`
+	Seconds int    `json:"seconds"`
+}
+
+// stats aggregates entries of logs.
+type stats struct {
+	Entries    int            `json:"entries"`
+	Failures   int            `json:"failures"`
+	Priorities map[string]int `json:"priorities"`
+	Tags       []count        `json:"tags"`
+	Pids       []count        `json:"pids"`
+	Histogram  []bucket       `json:"lines_per_second"`
+	Peaks      []count        `json:"peak_seconds"`
+
+	tags, pids, seconds map[string]int
+}
+
+func newStats() *stats {
+	return &stats{
+		Priorities: map[string]int{},
+		tags:       map[string]int{},
+		pids:       map[string]int{},
+		seconds:    map[string]int{},
+	}
+}
+
+// Read counts entries read from `r`.
+func (s *stats) Read(r io.Reader) {
+	for res := range newParser(nil).Entries(r, nil) {
+		if res.err != nil || res.entry.Format() == "raw" {
+			s.Failures++
+			continue
+		}
+		entry := res.entry
+		s.Entries++
+		s.Priorities[entry["priority"]]++
+		s.tags[entry["tag"]]++
+		if pid, ok := entry["pid"]; ok {
+			s.pids[pid]++
+		}
+		if sec := entry["time"]; sec != "" {
+			if i := strings.Index(sec, "."); i >= 0 {
+				sec = sec[:i]
+			}
+			s.seconds[sec]++
+		}
+	}
+}
+
+// summarize fills exported fields from counters, with `top` tags, pids and seconds.
+func (s *stats) summarize(top int) {
+	s.Tags = topCounts(s.tags, top)
+	s.Pids = topCounts(s.pids, top)
+	s.Peaks = topCounts(s.seconds, top)
+
+	s.Histogram = make([]bucket, len(histogramBounds))
+	for i, lower := range histogramBounds {
+		if i+1 < len(histogramBounds) {
+			s.Histogram[i].Range = fmt.Sprintf("%d-%d", lower, histogramBounds[i+1]-1)
+		} else {
+			s.Histogram[i].Range = fmt.Sprintf("%d-", lower)
+		}
+	}
+	for _, n := range s.seconds {
+		i := sort.Search(len(histogramBounds), func(i int) bool { return histogramBounds[i] > n }) - 1
+		s.Histogram[i].Seconds++
+	}
+}
+
+// WriteText prints summary for humans.
+func (s *stats) WriteText(w io.Writer, top int) {
+	s.summarize(top)
+	fmt.Fprintf(w, "Entries: %d\n", s.Entries)
+	fmt.Fprintf(w, "Parse failures: %d\n", s.Failures)
+	fmt.Fprintf(w, "\nPriorities:\n")
+	priorities := make([]string, 0, len(s.Priorities))
+	for p := range s.Priorities {
+		priorities = append(priorities, p)
+	}
+	sort.Slice(priorities, func(i, j int) bool {
+		a, b := priorityLevel(priorities[i]), priorityLevel(priorities[j])
+		if a != b {
+			return a < b
+		}
+		return priorities[i] < priorities[j]
+	})
+	for _, p := range priorities {
+		fmt.Fprintf(w, "  %s  %d\n", p, s.Priorities[p])
+	}
+	for _, section := range []struct {
+		title  string
+		counts []count
+	}{
+		{"Tags", s.Tags},
+		{"Pids", s.Pids},
+		{"Peak seconds", s.Peaks},
+	} {
+		if top > 0 {
+			fmt.Fprintf(w, "\n%s (top %d):\n", section.title, top)
+		} else {
+			fmt.Fprintf(w, "\n%s:\n", section.title)
+		}
+		for _, c := range section.counts {
+			fmt.Fprintf(w, "  %-24s %d\n", c.Value, c.Count)
+		}
+	}
+	fmt.Fprintf(w, "\nLines per second:\n")
+	for _, b := range s.Histogram {
+		fmt.Fprintf(w, "  %-12s %d seconds\n", b.Range, b.Seconds)
+	}
+}
+
+// WriteJSON prints summary as JSON.
+func (s *stats) WriteJSON(w io.Writer, top int) error {
+	s.summarize(top)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// topCounts returns `top` values in descending order of counts.
+func topCounts(counts map[string]int, top int) []count {
+	list := make([]count, 0, len(counts))
+	for value, n := range counts {
+		list = append(list, count{value, n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Value < list[j].Value
+	})
+	if top > 0 && len(list) > top {
+		list = list[:top]
+	}
+	return list
+}
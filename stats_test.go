@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const statsInput = "12-28 18:54:07.180   930   931 I ActivityManager: message_1\n" +
+	"12-28 18:54:07.280   930   931 I ActivityManager: message_2\n" +
+	"12-28 18:54:07.380  1234  1234 E AndroidRuntime: message_3\n" +
+	"12-28 18:54:08.180   930   931 W ActivityManager: message_4\n" +
+	"not a logcat line\n"
+
+func TestStats_Read(t *testing.T) {
+	s := newStats()
+	s.Read(strings.NewReader(statsInput))
+	s.summarize(1)
+
+	if s.Entries != 4 || s.Failures != 1 {
+		t.Errorf("expected %d, %d to eq 4, 1", s.Entries, s.Failures)
+	}
+	expectPriorities := map[string]int{"I": 2, "E": 1, "W": 1}
+	if !reflect.DeepEqual(s.Priorities, expectPriorities) {
+		t.Errorf("expected %v to eq %v", s.Priorities, expectPriorities)
+	}
+	expectTags := []count{{"ActivityManager", 3}}
+	if !reflect.DeepEqual(s.Tags, expectTags) {
+		t.Errorf("expected %v to eq %v", s.Tags, expectTags)
+	}
+	expectPeaks := []count{{"12-28 18:54:07", 3}}
+	if !reflect.DeepEqual(s.Peaks, expectPeaks) {
+		t.Errorf("expected %v to eq %v", s.Peaks, expectPeaks)
+	}
+	expectHistogram := []bucket{{"1-9", 2}, {"10-99", 0}, {"100-999", 0}, {"1000-9999", 0}, {"10000-", 0}}
+	if !reflect.DeepEqual(s.Histogram, expectHistogram) {
+		t.Errorf("expected %v to eq %v", s.Histogram, expectHistogram)
+	}
+}
+
+func TestStats_WriteText(t *testing.T) {
+	expect := "Entries: 4\n" +
+		"Parse failures: 1\n" +
+		"\n" +
+		"Priorities:\n" +
+		"  I  2\n" +
+		"  W  1\n" +
+		"  E  1\n" +
+		"\n" +
+		"Tags (top 2):\n" +
+		"  ActivityManager          3\n" +
+		"  AndroidRuntime           1\n" +
+		"\n" +
+		"Pids (top 2):\n" +
+		"  930                      3\n" +
+		"  1234                     1\n" +
+		"\n" +
+		"Peak seconds (top 2):\n" +
+		"  12-28 18:54:07           3\n" +
+		"  12-28 18:54:08           1\n" +
+		"\n" +
+		"Lines per second:\n" +
+		"  1-9          2 seconds\n" +
+		"  10-99        0 seconds\n" +
+		"  100-999      0 seconds\n" +
+		"  1000-9999    0 seconds\n" +
+		"  10000-       0 seconds\n"
+
+	s := newStats()
+	s.Read(strings.NewReader(statsInput))
+	w := new(bytes.Buffer)
+	s.WriteText(w, 2)
+	if w.String() != expect {
+		t.Errorf("\n  result: %q\n  expect: %q", w.String(), expect)
+	}
+}
+
+func TestStats_WriteText_Priorities(t *testing.T) {
+	expect := "Priorities:\n" +
+		"  I  1\n" +
+		"  A  2\n" +
+		"  F  1\n" +
+		"\n"
+
+	s := newStats()
+	s.Read(strings.NewReader("[ 12-28 18:54:07.180   930:  931 A/tag ]\nmessage_1\n\n" +
+		"[ 12-28 18:54:07.180   930:  931 F/tag ]\nmessage_2\n\n" +
+		"[ 12-28 18:54:07.180   930:  931 A/tag ]\nmessage_3\n\n" +
+		"[ 12-28 18:54:07.180   930:  931 I/tag ]\nmessage_4\n\n"))
+	w := new(bytes.Buffer)
+	s.WriteText(w, 1)
+	if !strings.Contains(w.String(), expect) {
+		t.Errorf("expected %q to contain %q", w.String(), expect)
+	}
+}
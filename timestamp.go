@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+// logcatTimeLayout represents layout of `time` field of logcat.
+// Fractional seconds are accepted by time.Parse, even though it isn't in the layout.
+const logcatTimeLayout = "01-02 15:04:05"
+
+// Derived columns of elapsed time, in seconds.
+const (
+	// ElapsedColumn represents column name of time since the first entry.
+	ElapsedColumn = "elapsed"
+	// DeltaColumn represents column name of time since the previous entry.
+	DeltaColumn = "delta"
+	// PidDeltaColumn represents column name of time since the previous entry of the same pid.
+	PidDeltaColumn = "pid_delta"
+)
+
+const (
+	// isoTimeLayout represents layout of ISO 8601 timestamps, which are output instead of `time` field.
+	isoTimeLayout = "2006-01-02T15:04:05.000"
+	// isoZoneTimeLayout represents layout of ISO 8601 timestamps, with timezone.
+	isoZoneTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+	// zoneLayout represents layout of timezone, which is output with `-v zone` modifier.
+	zoneLayout = "-0700"
+)
+
+// parseTime parses `time` field of logcat, as a time in the `year`.
+// If the field is already an ISO 8601 timestamp, or seconds of epoch or monotonic time,
+// `year` is ignored.
+func parseTime(str string, year int) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02T15:04:05", str); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(isoZoneTimeLayout, str); err == nil {
+		return t, nil
+	}
+	if sec, err := strconv.ParseFloat(str, 64); err == nil {
+		return time.Unix(0, int64(sec*float64(time.Second))).UTC(), nil
+	}
+	t, err := time.Parse(logcatTimeLayout, str)
+	if err != nil {
+		return t, err
+	}
+	return withYear(t, year), nil
+}
+
+// withYear returns wall clock of `t` in the `year`, as UTC.
+func withYear(t time.Time, year int) time.Time {
+	return time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// yearResolver infers years of logcat timestamps, which don't have a year.
+// When timestamps wrap from December to January, the year is incremented.
+type yearResolver struct {
+	year    int
+	end     time.Time
+	last    time.Time
+	started bool
+}
+
+// newYearResolver creates yearResolver.
+// `year` is the year of the first entry. If it is 0, the year is inferred from `end`,
+// which is the time when the log was finished, like the modification time of the file.
+// If `end` is zero, the current time is used.
+func newYearResolver(year int, end time.Time) *yearResolver {
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return &yearResolver{year: year, end: end}
+}
+
+// Resolve parses `time` field of logcat, with the inferred year.
+func (y *yearResolver) Resolve(str string) (time.Time, error) {
+	t, err := time.Parse(logcatTimeLayout, str)
+	if err != nil {
+		return t, err
+	}
+	if !y.started {
+		y.started = true
+		if y.year == 0 {
+			// The log may be started in the previous year of its end.
+			end := withYear(y.end, y.end.Year())
+			y.year = end.Year()
+			if withYear(t, y.year).After(end) {
+				y.year--
+			}
+		}
+	} else if y.last.Month() == time.December && t.Month() == time.January {
+		y.year++
+	}
+	y.last = t
+	return withYear(t, y.year), nil
+}
+
+// newRolloverResolver creates yearResolver, which is only used to handle rollover.
+// Resolved times are only comparable with each other.
+func newRolloverResolver() *yearResolver {
+	// Any leap year is fine.
+	return newYearResolver(2000, time.Time{})
+}
+
+// resolveTime parses `time` field of entries, which are given in order of the log.
+// Epoch, monotonic and ISO 8601 times are parsed as they are.
+func resolveTime(years *yearResolver, str string) (time.Time, error) {
+	if t, err := years.Resolve(str); err == nil {
+		return t, nil
+	}
+	return parseTime(str, 0)
+}
+
+// loadLocation returns location of the name, like `UTC`, `Asia/Tokyo` or `+0900`.
+func loadLocation(name string) (*time.Location, error) {
+	if t, err := time.Parse(zoneLayout, name); err == nil {
+		return t.Location(), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid timezone: %s", name)
+	}
+	return loc, nil
+}
+
+// timeConverter rewrites `time` field of entries.
+type timeConverter struct {
+	years    *yearResolver
+	from, to *time.Location
+	iso      bool
+}
+
+// newTimeConverter creates timeConverter.
+// If `to` is nil, timezone isn't converted. If `from` is nil, local timezone is used.
+// Timezones of entries, which are output with `-v zone`, take precedence over `from`.
+func newTimeConverter(years *yearResolver, from, to *time.Location, iso bool) *timeConverter {
+	if from == nil {
+		from = time.Local
+	}
+	return &timeConverter{years: years, from: from, to: to, iso: iso}
+}
+
+// Convert rewrites `time` field of `item`.
+func (c *timeConverter) Convert(item logcat.Entry) {
+	t, err := time.Parse(isoZoneTimeLayout, item["time"])
+	zoned := err == nil
+	if err != nil {
+		// logcat timestamp, which has neither year nor timezone.
+		if t, err = c.years.Resolve(item["time"]); err != nil {
+			return
+		}
+		loc := c.from
+		if zone, err := time.Parse(zoneLayout, item[ZoneKey]); err == nil {
+			loc, zoned = zone.Location(), true
+		}
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	}
+	if c.to == nil {
+		// keep the offset, if it is known.
+		if zoned {
+			item["time"] = t.Format(isoZoneTimeLayout)
+		} else {
+			item["time"] = t.Format(isoTimeLayout)
+		}
+		return
+	}
+
+	t = t.In(c.to)
+	if c.iso {
+		item["time"] = t.Format(isoZoneTimeLayout)
+	} else {
+		item["time"] = t.Format(logcatTimeLayout + ".000")
+	}
+	if _, ok := item[ZoneKey]; ok {
+		item[ZoneKey] = t.Format(zoneLayout)
+	}
+}
+
+// elapsedCalculator computes elapsed time columns of entries.
+// Entries must be given in order of the log, including entries which aren't output.
+type elapsedCalculator struct {
+	years       *yearResolver
+	first, last time.Time
+	pids        map[string]time.Time
+	started     bool
+}
+
+func newElapsedCalculator() *elapsedCalculator {
+	return &elapsedCalculator{years: newRolloverResolver(), pids: map[string]time.Time{}}
+}
+
+// Calculate sets elapsed time columns of `item`.
+// Columns are empty, if they can't be computed.
+func (c *elapsedCalculator) Calculate(item logcat.Entry) {
+	item[ElapsedColumn], item[DeltaColumn], item[PidDeltaColumn] = "", "", ""
+	t, err := resolveTime(c.years, item["time"])
+	if err != nil {
+		return
+	}
+	if !c.started {
+		c.started = true
+		c.first, c.last = t, t
+	} else {
+		item[DeltaColumn] = seconds(t.Sub(c.last))
+	}
+	item[ElapsedColumn] = seconds(t.Sub(c.first))
+	c.last = t
+
+	pid, ok := item["pid"]
+	if !ok {
+		return
+	}
+	if last, ok := c.pids[pid]; ok {
+		item[PidDeltaColumn] = seconds(t.Sub(last))
+	}
+	c.pids[pid] = t
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func TestYearResolver_Resolve(t *testing.T) {
+	tests := []struct {
+		year   int
+		end    time.Time
+		times  []string
+		expect []string
+	}{
+		// specified year, and rollover.
+		{2017, time.Time{},
+			[]string{"12-31 23:59:59.999", "01-01 00:00:00.000", "01-01 00:00:01.000"},
+			[]string{"2017-12-31T23:59:59.999", "2018-01-01T00:00:00.000", "2018-01-01T00:00:01.000"}},
+		// inferred from the end.
+		{0, time.Date(2018, 3, 1, 0, 0, 0, 0, time.Local),
+			[]string{"02-28 10:00:00.000"},
+			[]string{"2018-02-28T10:00:00.000"}},
+		// inferred from the end, across new year.
+		{0, time.Date(2018, 1, 1, 0, 10, 0, 0, time.Local),
+			[]string{"12-31 23:59:00.000", "01-01 00:05:00.000"},
+			[]string{"2017-12-31T23:59:00.000", "2018-01-01T00:05:00.000"}},
+	}
+	for _, test := range tests {
+		years := newYearResolver(test.year, test.end)
+		for i, str := range test.times {
+			res, err := years.Resolve(str)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Format(isoTimeLayout) != test.expect[i] {
+				t.Errorf("expected %q to eq %q", res.Format(isoTimeLayout), test.expect[i])
+			}
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		str    string
+		expect time.Time
+	}{
+		{"12-28 18:54:07.180", time.Date(2017, 12, 28, 18, 54, 7, 180000000, time.UTC)},
+		{"12-28 18:54:07", time.Date(2017, 12, 28, 18, 54, 7, 0, time.UTC)},
+		{"2016-12-28T18:54:07.180", time.Date(2016, 12, 28, 18, 54, 7, 180000000, time.UTC)},
+		{"2016-12-28T18:54:07.180+09:00", time.Date(2016, 12, 28, 9, 54, 7, 180000000, time.UTC)},
+		{"2016-12-28T09:54:07.180Z", time.Date(2016, 12, 28, 9, 54, 7, 180000000, time.UTC)},
+		{"12.5", time.Date(1970, 1, 1, 0, 0, 12, 500000000, time.UTC)},
+	}
+	for _, test := range tests {
+		res, err := parseTime(test.str, 2017)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Equal(test.expect) {
+			t.Errorf("expected %v to eq %v", res, test.expect)
+		}
+	}
+}
+
+func TestTimeConverter_Convert(t *testing.T) {
+	jst, _ := loadLocation("+0900")
+	tests := []struct {
+		from   *time.Location
+		iso    bool
+		entry  logcat.Entry
+		expect logcat.Entry
+	}{
+		{jst, false,
+			logcat.Entry{"time": "12-28 08:54:07.180"},
+			logcat.Entry{"time": "12-27 23:54:07.180"}},
+		{jst, true,
+			logcat.Entry{"time": "01-01 08:54:07.180"},
+			logcat.Entry{"time": "2016-12-31T23:54:07.180Z"}},
+		// timezone in the log takes precedence.
+		{jst, false,
+			logcat.Entry{"time": "12-28 18:54:07.180", "zone": "-0500"},
+			logcat.Entry{"time": "12-28 23:54:07.180", "zone": "+0000"}},
+		{time.UTC, false,
+			logcat.Entry{"message": "raw"},
+			logcat.Entry{"message": "raw"}},
+	}
+	for _, test := range tests {
+		times := newTimeConverter(newYearResolver(2017, time.Time{}), test.from, time.UTC, test.iso)
+		times.Convert(test.entry)
+		if !reflect.DeepEqual(test.entry, test.expect) {
+			t.Errorf("expected %v to eq %v", test.entry, test.expect)
+		}
+	}
+}
+
+func TestTimeConverter_Convert_ISO(t *testing.T) {
+	tests := []struct {
+		entry  logcat.Entry
+		expect logcat.Entry
+	}{
+		{logcat.Entry{"time": "12-28 18:54:07.180"},
+			logcat.Entry{"time": "2017-12-28T18:54:07.180"}},
+		// offset is kept, if it is known.
+		{logcat.Entry{"time": "12-28 18:54:07.180", "zone": "-0500"},
+			logcat.Entry{"time": "2017-12-28T18:54:07.180-05:00", "zone": "-0500"}},
+		{logcat.Entry{"time": "2017-12-28T18:54:07.180+09:00"},
+			logcat.Entry{"time": "2017-12-28T18:54:07.180+09:00"}},
+	}
+	for _, test := range tests {
+		times := newTimeConverter(newYearResolver(2017, time.Time{}), nil, nil, true)
+		times.Convert(test.entry)
+		if !reflect.DeepEqual(test.entry, test.expect) {
+			t.Errorf("expected %v to eq %v", test.entry, test.expect)
+		}
+	}
+}
+
+func TestLoadLocation_Invalid(t *testing.T) {
+	if _, err := loadLocation("Mars/Olympus"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestElapsedCalculator_Calculate(t *testing.T) {
+	entries := []logcat.Entry{
+		{"time": "12-31 23:59:59.000", "pid": "930"},
+		{"time": "12-31 23:59:59.500", "pid": "1"},
+		{"message": "raw"},
+		{"time": "01-01 00:00:01.250", "pid": "930"},
+	}
+	expect := [][]string{
+		{"0.000", "", ""},
+		{"0.500", "0.500", ""},
+		{"", "", ""},
+		{"2.250", "1.750", "2.250"},
+	}
+
+	elapsed := newElapsedCalculator()
+	for i, entry := range entries {
+		elapsed.Calculate(entry)
+		res := []string{entry[ElapsedColumn], entry[DeltaColumn], entry[PidDeltaColumn]}
+		if !reflect.DeepEqual(res, expect[i]) {
+			t.Errorf("expected %v to eq %v", res, expect[i])
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+const (
+	// FormatCSV represents output format `csv`
+	FormatCSV = "csv"
+	// FormatJSONL represents output format `jsonl`
+	FormatJSONL = "jsonl"
+	// FormatXLSX represents output format `xlsx`
+	FormatXLSX = "xlsx"
+	// FormatSQLite represents output format `sqlite`
+	FormatSQLite = "sqlite"
+	// FormatHTML represents output format `html`
+	FormatHTML = "html"
+	// FormatMarkdown represents output format `markdown`
+	FormatMarkdown = "markdown"
+	// FormatParquet represents output format `parquet`
+	FormatParquet = "parquet"
+	// FormatBulk represents output format `bulk`, for Elasticsearch bulk API.
+	FormatBulk = "bulk"
+)
+
+// Writer is the interface that writes logcat.Entry to a specific output format.
+// If a Writer also implements io.Closer, Close is called after the conversion,
+// even if the conversion is canceled.
+type Writer interface {
+	Write(item logcat.Entry) error
+	Flush() error
+}
+
+type outputFormat struct {
+	ext string
+	// newWriter creates Writer which writes to a stream.
+	newWriter func(w io.Writer, params cmdParams) (Writer, error)
+	// newFileWriter creates Writer which writes to a file directly,
+	// for formats which can't be streamed.
+	newFileWriter func(path string, params cmdParams) (Writer, error)
+}
+
+var outputFormats = map[string]outputFormat{
+	FormatCSV: {
+		ext: ".csv",
+		newWriter: func(w io.Writer, params cmdParams) (Writer, error) {
+			return NewWriter(w, params.encode, params.osName, params.columns, params.delimiter), nil
+		},
+	},
+	FormatJSONL: {
+		ext: ".jsonl",
+		newWriter: func(w io.Writer, params cmdParams) (Writer, error) {
+			return NewJSONWriter(w, params.columns), nil
+		},
+	},
+	FormatBulk: {
+		ext: ".ndjson",
+		newWriter: func(w io.Writer, params cmdParams) (Writer, error) {
+			return NewBulkWriter(w, params.index, params.columns), nil
+		},
+	},
+	FormatXLSX: {
+		ext: ".xlsx",
+		newWriter: func(w io.Writer, params cmdParams) (Writer, error) {
+			return NewXlsxWriter(w, params.columns), nil
+		},
+	},
+	FormatHTML: {
+		ext: ".html",
+		newWriter: func(w io.Writer, params cmdParams) (Writer, error) {
+			return NewHTMLWriter(w, params.columns), nil
+		},
+	},
+	FormatMarkdown: {
+		ext: ".md",
+		newWriter: func(w io.Writer, params cmdParams) (Writer, error) {
+			return NewMarkdownWriter(w, params.columns), nil
+		},
+	},
+	FormatParquet: {
+		ext: ".parquet",
+		newWriter: func(w io.Writer, params cmdParams) (Writer, error) {
+			return NewParquetWriter(w, newYearResolver(params.year, params.modTime))
+		},
+	},
+	FormatSQLite: {
+		ext: ".db",
+		newFileWriter: func(path string, params cmdParams) (Writer, error) {
+			return NewSqliteWriter(path, params.source, params.columns)
+		},
+	},
+}
+
+// lookupFormat returns outputFormat of the `format`.
+// If `format` is empty, FormatCSV is used.
+func lookupFormat(format string) (outputFormat, error) {
+	if format == "" {
+		format = FormatCSV
+	}
+	f, ok := outputFormats[format]
+	if !ok {
+		return f, fmt.Errorf("Unknown format: %s", format)
+	}
+	return f, nil
+}
+
+// newWriter creates Writer which writes to `params.writer` with `params.format`.
+// If the format can't be streamed, it writes to `params.output` instead.
+func newWriter(params cmdParams) (Writer, error) {
+	f, err := lookupFormat(params.format)
+	if err != nil {
+		return nil, err
+	}
+	if f.newFileWriter != nil {
+		if params.output == "" {
+			return nil, fmt.Errorf("Output file is required for %s format", params.format)
+		}
+		return f.newFileWriter(params.output, params)
+	}
+	return f.newWriter(params.writer, params)
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+// Cell styles, index of cellXfs in styles.xml.
+const (
+	xlsxStyleNone = iota
+	xlsxStyleHeader
+	xlsxStyleError
+	xlsxStyleWarn
+)
+
+// Limits of Excel. Rows over the limit are written into next sheets, and long cells are truncated.
+const (
+	xlsxMaxRows       = 1048576
+	xlsxMaxCellLength = 32767
+)
+
+// xlsxColumnWidths represents width of each column.
+var xlsxColumnWidths = map[string]int{
+	"time":        20,
+	"pid":         8,
+	"tid":         8,
+	"priority":    8,
+	"tag":         24,
+	Message:       120,
+	ProcessColumn: 32,
+}
+
+// XlsxWriter writes logcat.Entry to a Excel workbook.
+type XlsxWriter struct {
+	zip     *zip.Writer
+	sheet   *bufio.Writer
+	columns []string
+	rows    int   // rows of the current sheet, including the header.
+	sheets  []int // rows of finished sheets.
+	maxRows int
+	err     error
+}
+
+// NewXlsxWriter creates new XlsxWriter.
+// If `columns` is empty, Columns is used.
+func NewXlsxWriter(w io.Writer, columns []string) *XlsxWriter {
+	if len(columns) == 0 {
+		columns = Columns
+	}
+	res := &XlsxWriter{
+		zip:     zip.NewWriter(w),
+		columns: columns,
+		maxRows: xlsxMaxRows,
+	}
+	res.startSheet()
+	return res
+}
+
+// Write writes logcat.Entry as a row.
+func (f *XlsxWriter) Write(item logcat.Entry) error {
+	if item == nil || f.err != nil {
+		return nil
+	}
+
+	values := make([]string, len(f.columns))
+	for i, key := range f.columns {
+		values[i] = item[key]
+	}
+	if f.rows >= f.maxRows {
+		f.finishSheet()
+		f.startSheet()
+		if f.err != nil {
+			return f.err
+		}
+	}
+	style := xlsxStyleNone
+	switch item["priority"] {
+	case "E", "F", "A":
+		style = xlsxStyleError
+	case "W":
+		style = xlsxStyleWarn
+	}
+	f.writeRow(values, style, false)
+	return nil
+}
+
+// Flush finishes the workbook, and flushes it to file.
+func (f *XlsxWriter) Flush() error {
+	if f.err != nil {
+		return f.err
+	}
+	f.finishSheet()
+	if f.err != nil {
+		return f.err
+	}
+
+	var types, rels, sheets, names string
+	lastCol := columnName(len(f.columns) - 1)
+	for i, rows := range f.sheets {
+		name := "logcat"
+		if i > 0 {
+			name += strconv.Itoa(i + 1)
+		}
+		types += fmt.Sprintf(xlsxSheetContentType, i+1)
+		rels += fmt.Sprintf(xlsxSheetRel, i+1, i+1)
+		sheets += fmt.Sprintf(xlsxSheet, name, i+1, i+1)
+		names += fmt.Sprintf(xlsxFilterName, i, name, lastCol, rows)
+	}
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", fmt.Sprintf(xlsxContentTypes, types)},
+		{"_rels/.rels", xlsxRels},
+		{"xl/_rels/workbook.xml.rels", fmt.Sprintf(xlsxWorkbookRels, rels, len(f.sheets)+1)},
+		{"xl/styles.xml", xlsxStyles},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, sheets, names)},
+	}
+	for _, part := range parts {
+		w, err := f.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, part.body); err != nil {
+			return err
+		}
+	}
+	return f.zip.Close()
+}
+
+// startSheet starts a new worksheet.
+// Worksheets are streamed, so they must be written before other parts of the archive.
+func (f *XlsxWriter) startSheet() {
+	sheet, err := f.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(f.sheets)+1))
+	if err != nil {
+		f.err = err
+		return
+	}
+	f.sheet = bufio.NewWriter(sheet)
+	f.rows = 0
+	f.writeSheetHeader()
+}
+
+// finishSheet closes the current worksheet.
+func (f *XlsxWriter) finishSheet() {
+	fmt.Fprintf(f.sheet, `</sheetData><autoFilter ref="A1:%s%d"/></worksheet>`, columnName(len(f.columns)-1), f.rows)
+	if err := f.sheet.Flush(); err != nil {
+		f.err = err
+	}
+	f.sheets = append(f.sheets, f.rows)
+}
+
+func (f *XlsxWriter) writeSheetHeader() {
+	f.sheet.WriteString(xml.Header)
+	f.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	f.sheet.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+	f.sheet.WriteString(`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`)
+	f.sheet.WriteString(`</sheetView></sheetViews><cols>`)
+	for i, col := range f.columns {
+		width, ok := xlsxColumnWidths[col]
+		if !ok {
+			width = 16
+		}
+		fmt.Fprintf(f.sheet, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+	}
+	f.sheet.WriteString(`</cols><sheetData>`)
+	f.writeRow(f.columns, xlsxStyleHeader, true)
+}
+
+func (f *XlsxWriter) writeRow(values []string, style int, header bool) {
+	f.rows++
+	fmt.Fprintf(f.sheet, `<row r="%d">`, f.rows)
+	for i, value := range values {
+		ref := columnName(i) + strconv.Itoa(f.rows)
+		if !header && isNumericColumn(f.columns[i]) && isInt(value) {
+			fmt.Fprintf(f.sheet, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, value)
+			continue
+		}
+		fmt.Fprintf(f.sheet, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+		xml.EscapeText(f.sheet, []byte(truncateCell(strings.Map(xmlChar, value))))
+		f.sheet.WriteString(`</t></is></c>`)
+	}
+	f.sheet.WriteString(`</row>`)
+}
+
+// columnName returns name of zero-based column index, like `A`, `AB`.
+func columnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+func isNumericColumn(col string) bool {
+	return col == "pid" || col == "tid" || col == CountColumn || col == FilesColumn
+}
+
+func isInt(str string) bool {
+	_, err := strconv.Atoi(str)
+	return err == nil
+}
+
+// truncateCell truncates `value` to the max length of a cell, which is counted in UTF-16.
+func truncateCell(value string) string {
+	n := 0
+	for i, r := range value {
+		if n += utf16.RuneLen(r); n > xlsxMaxCellLength {
+			return value[:i]
+		}
+	}
+	return value
+}
+
+// xmlChar drops characters which are not allowed in XML.
+func xmlChar(r rune) rune {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return r
+	case r < 0x20, r == 0xFFFE, r == 0xFFFF:
+		return -1
+	}
+	return r
+}
+
+// xlsxContentTypes needs overrides of worksheets.
+const xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`%s` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// xlsxWorkbookRels needs relationships of worksheets, and the id of styles.
+const xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`%s` +
+	`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// xlsxWorkbook needs sheets, and names of their auto-filter ranges.
+const xlsxWorkbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets>%s</sheets><definedNames>%s</definedNames></workbook>`
+
+// Parts of a worksheet, which are formatted with the sheet number.
+const (
+	xlsxSheetContentType = `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`
+	xlsxSheetRel         = `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`
+	xlsxSheet            = `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`
+	// xlsxFilterName needs index of the sheet, its name, and the last column and row of the auto-filter range.
+	xlsxFilterName = `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">%s!$A$1:$%s$%d</definedName>`
+)
+
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="4">` +
+	`<fill><patternFill patternType="none"/></fill>` +
+	`<fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFFFC7CE"/><bgColor indexed="64"/></patternFill></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFFFEB9C"/><bgColor indexed="64"/></patternFill></fill>` +
+	`</fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="0" fontId="0" fillId="2" borderId="0" xfId="0" applyFill="1"/>` +
+	`<xf numFmtId="0" fontId="0" fillId="3" borderId="0" xfId="0" applyFill="1"/>` +
+	`</cellXfs>` +
+	`</styleSheet>`
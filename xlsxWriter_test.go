@@ -0,0 +1,132 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/ujiro99/logcatf/logcat"
+)
+
+func readXlsxPart(t *testing.T, b []byte, name string) string {
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range r.File {
+		if file.Name != name {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		body, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+	t.Fatalf("%s is not found", name)
+	return ""
+}
+
+func TestXlsxWriter_Write(t *testing.T) {
+
+	entries := []logcat.Entry{
+		{"time": "12-28 18:54:07.180", "pid": "930", "priority": "E", "tag": "auditd", "message": "<error> & \x01"},
+		{"time": "12-28 18:54:07.181", "pid": "930", "priority": "W", "tag": "auditd", "message": "warn"},
+		{"time": "12-28 18:54:07.182", "pid": "930", "priority": "I", "tag": "auditd", "message": "123"},
+	}
+	expected := []string{
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`,
+		`<col min="6" max="6" width="120" customWidth="1"/>`,
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">time</t></is></c>`,
+		`<c r="B2" s="2"><v>930</v></c><c r="C2" s="2" t="inlineStr"><is><t xml:space="preserve"></t></is></c>`,
+		`<c r="F2" s="2" t="inlineStr"><is><t xml:space="preserve">&lt;error&gt; &amp; </t></is></c>`,
+		`<c r="F3" s="3" t="inlineStr"><is><t xml:space="preserve">warn</t></is></c>`,
+		`<c r="F4" s="0" t="inlineStr"><is><t xml:space="preserve">123</t></is></c>`,
+		`<autoFilter ref="A1:F4"/>`,
+	}
+
+	writer := new(bytes.Buffer)
+	xlsxWriter := NewXlsxWriter(writer, nil)
+	for _, entry := range entries {
+		xlsxWriter.Write(entry)
+	}
+	if err := xlsxWriter.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := readXlsxPart(t, writer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, e := range expected {
+		if !strings.Contains(sheet, e) {
+			t.Errorf("expected %q to contain %q", sheet, e)
+		}
+	}
+	workbook := readXlsxPart(t, writer.Bytes(), "xl/workbook.xml")
+	if e := "logcat!$A$1:$F$4"; !strings.Contains(workbook, e) {
+		t.Errorf("expected %q to contain %q", workbook, e)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for col, expected := range map[int]string{0: "A", 5: "F", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if name := columnName(col); name != expected {
+			t.Errorf("expected %q to eq %q", name, expected)
+		}
+	}
+}
+
+func TestXlsxWriter_Write_Sheets(t *testing.T) {
+	writer := new(bytes.Buffer)
+	xlsxWriter := NewXlsxWriter(writer, []string{"message"})
+	xlsxWriter.maxRows = 3
+	for _, message := range []string{"1", "2", "3", "4", "5"} {
+		if err := xlsxWriter.Write(logcat.Entry{"message": message}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := xlsxWriter.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		"xl/worksheets/sheet1.xml": {`<c r="A2" s="0" t="inlineStr"><is><t xml:space="preserve">1</t></is></c>`, `<autoFilter ref="A1:A3"/>`},
+		"xl/worksheets/sheet2.xml": {`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">message</t></is></c>`,
+			`<c r="A2" s="0" t="inlineStr"><is><t xml:space="preserve">3</t></is></c>`},
+		"xl/worksheets/sheet3.xml": {`<c r="A2" s="0" t="inlineStr"><is><t xml:space="preserve">5</t></is></c>`, `<autoFilter ref="A1:A2"/>`},
+		"xl/workbook.xml": {`<sheet name="logcat3" sheetId="3" r:id="rId3"/>`,
+			`<definedName name="_xlnm._FilterDatabase" localSheetId="2" hidden="1">logcat3!$A$1:$A$2</definedName>`},
+		"xl/_rels/workbook.xml.rels": {`Target="worksheets/sheet3.xml"`, `<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles"`},
+		"[Content_Types].xml":        {`<Override PartName="/xl/worksheets/sheet3.xml"`},
+	}
+	for name, list := range expected {
+		part := readXlsxPart(t, writer.Bytes(), name)
+		for _, e := range list {
+			if !strings.Contains(part, e) {
+				t.Errorf("expected %q to contain %q", part, e)
+			}
+		}
+	}
+}
+
+func TestTruncateCell(t *testing.T) {
+	tests := []struct {
+		value  string
+		expect int
+	}{
+		{strings.Repeat("a", xlsxMaxCellLength), xlsxMaxCellLength},
+		{strings.Repeat("a", xlsxMaxCellLength+1), xlsxMaxCellLength},
+		{strings.Repeat("あ", xlsxMaxCellLength+1), xlsxMaxCellLength * 3},
+		{strings.Repeat("😀", xlsxMaxCellLength), xlsxMaxCellLength / 2 * 4},
+	}
+	for _, test := range tests {
+		if res := len(truncateCell(test.value)); res != test.expect {
+			t.Errorf("expected %d to eq %d", res, test.expect)
+		}
+	}
+}